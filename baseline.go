@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+)
+
+// baseline is a set of already known warnings.
+//
+// Warnings are matched by their fingerprints. Identical warnings
+// can appear several times, so fingerprints are counted.
+type baseline struct {
+	known map[string]int
+
+	// ignoreRepo is set for a local mode, where repository
+	// location depends on how the path was specified.
+	ignoreRepo bool
+}
+
+// loadBaseline reads baseline warnings from previously saved lint results.
+func loadBaseline(filename string, ignoreRepo bool) (*baseline, error) {
+	res, err := readResults(filename)
+	if err != nil {
+		return nil, err
+	}
+	b := &baseline{
+		known:      make(map[string]int, len(res.entries)),
+		ignoreRepo: ignoreRepo,
+	}
+	for _, e := range res.entries {
+		b.known[b.fingerprint(e)]++
+	}
+	return b, nil
+}
+
+func (b *baseline) fingerprint(e resultEntry) string {
+	if b.ignoreRepo {
+		e.repo = ""
+	}
+	return e.fingerprint()
+}
+
+// filter returns warnings that are not in the baseline.
+func (b *baseline) filter(repoPath string, warnings []warning) []warning {
+	var fresh []warning
+	for _, w := range warnings {
+		key := b.fingerprint(resultEntry{repo: repoPath, checker: w.checker, text: w.String()})
+		if b.known[key] > 0 {
+			b.known[key]--
+			continue
+		}
+		fresh = append(fresh, w)
+	}
+	return fresh
+}
+
+// baselineWriter saves all warnings in jsonl format,
+// so the file can be used as a baseline later.
+type baselineWriter struct {
+	jsonReporter
+	f *os.File
+}
+
+// newBaselineWriter creates or truncates the baseline file.
+// If resume is true, new warnings are appended to the file instead.
+func newBaselineWriter(filename string, resume bool) (*baselineWriter, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if resume {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(filename, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &baselineWriter{
+		jsonReporter: jsonReporter{w: f, lines: true},
+		f:            f,
+	}, nil
+}
+
+func (r *baselineWriter) finish(s *runSummary) error {
+	defer r.close()
+	if err := r.jsonReporter.finish(s); err != nil {
+		return err
+	}
+	return r.close()
+}
+
+// close closes the baseline file.
+// It's safe to call close several times.
+func (r *baselineWriter) close() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadResults(t *testing.T) {
+	want := []resultEntry{
+		{repo: "github.com/o/a", checker: "misspell", text: `README.md:3:5: "teh" is a misspelling of "the"`},
+		{repo: "github.com/o/b", checker: "unwanted file", text: "no README"},
+	}
+
+	tests := []struct {
+		name  string
+		data  string
+		repos int
+	}{
+		{
+			name:  "results.txt",
+			repos: 2,
+			data: "\tchecking o/a (1/3, made 0 requests so far) ...\n" +
+				"github.com/o/a: misspell: README.md:3:5: \"teh\" is a misspelling of \"the\"\n" +
+				"\tchecking o/b (2/3, made 2 requests so far) ...\n" +
+				"\n" +
+				"github.com/o/b: unwanted file: no README\n",
+		},
+		{
+			name:  "results.jsonl",
+			repos: 2,
+			data: `{"type":"warning","repo":"o/a","url":"https://github.com/o/a","checker":"misspell","file":"README.md","line":3,"column":5,"message":"\"teh\" is a misspelling of \"the\""}` + "\n" +
+				`{"type":"warning","repo":"o/b","url":"https://github.com/o/b","checker":"unwanted file","message":"no README"}` + "\n" +
+				`{"type":"summary","repos":2,"warnings":2,"requests":7}` + "\n",
+		},
+		{
+			name:  "results.json",
+			repos: 2,
+			data: `[
+  {"type":"warning","repo":"o/a","url":"https://github.com/o/a","checker":"misspell","file":"README.md","line":3,"column":5,"message":"\"teh\" is a misspelling of \"the\""},
+  {"type":"warning","repo":"o/b","url":"https://github.com/o/b","checker":"unwanted file","message":"no README"},
+  {"type":"summary","repos":2,"warnings":2,"requests":7}
+]
+`,
+		},
+	}
+
+	dir := t.TempDir()
+	for _, test := range tests {
+		filename := filepath.Join(dir, test.name)
+		if err := ioutil.WriteFile(filename, []byte(test.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		res, err := readResults(filename)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(res.entries, want) {
+			t.Errorf("%s: entries mismatch:\nhave: %q\nwant: %q", test.name, res.entries, want)
+		}
+		if res.repos != test.repos {
+			t.Errorf("%s: got %d repos, want %d", test.name, res.repos, test.repos)
+		}
+	}
+}
+
+func TestResultFingerprint(t *testing.T) {
+	base := resultEntry{repo: "github.com/o/a", checker: "misspell", text: "README.md:3:5: teh"}
+	same := []string{
+		"README.md:30:1: teh",
+		"README.md:4: teh",
+		"README.md: teh",
+	}
+	for _, text := range same {
+		e := base
+		e.text = text
+		if e.fingerprint() != base.fingerprint() {
+			t.Errorf("%q fingerprint differs from %q", text, base.text)
+		}
+	}
+
+	different := []resultEntry{
+		{repo: "github.com/o/b", checker: "misspell", text: "README.md:3:5: teh"},
+		{repo: "github.com/o/a", checker: "acronym", text: "README.md:3:5: teh"},
+		{repo: "github.com/o/a", checker: "misspell", text: "CONTRIBUTING.md:3:5: teh"},
+		{repo: "github.com/o/a", checker: "misspell", text: "README.md:3:5: recieve"},
+	}
+	for _, e := range different {
+		if e.fingerprint() == base.fingerprint() {
+			t.Errorf("%+v fingerprint matches %+v", e, base)
+		}
+	}
+}
+
+func TestBaselineFilter(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "baseline.txt")
+	data := "github.com/o/a: misspell: README.md:3:5: teh\n" +
+		"github.com/o/a: unwanted file: .a.swp: remove Vim swap file\n"
+	if err := ioutil.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := loadBaseline(filename, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := []warning{
+		// Moved after the file was edited.
+		{checker: "misspell", file: "README.md", line: 10, col: 1, message: "teh"},
+		// Same warning is reported twice, but it's known only once.
+		{checker: "misspell", file: "README.md", line: 12, col: 1, message: "teh"},
+		{checker: "unwanted file", file: ".a.swp", message: "remove Vim swap file"},
+		{checker: "unwanted file", file: ".b.swp", message: "remove Vim swap file"},
+	}
+	have := b.filter("github.com/o/a", warnings)
+	want := []warning{warnings[1], warnings[3]}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("filter mismatch:\nhave: %+v\nwant: %+v", have, want)
+	}
+
+	// Other repositories warnings are not known.
+	b, err = loadBaseline(filename, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := b.filter("github.com/o/b", warnings[2:3]); len(have) != 1 {
+		t.Errorf("o/b warnings are filtered by o/a baseline")
+	}
+
+	// In local mode, the repository location is ignored.
+	b, err = loadBaseline(filename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := b.filter("../a", warnings[2:3]); len(have) != 0 {
+		t.Errorf("local mode: known warning is reported: %+v", have)
+	}
+}
+
+func TestBaselineWriter(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "baseline.jsonl")
+	repo := &repository{owner: "o", name: "a", webURL: "https://github.com/o/a"}
+
+	w, err := newBaselineWriter(filename, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.report(repo, []warning{{checker: "misspell", file: "README.md", line: 1, message: "teh"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.finish(&runSummary{repos: 1, warnings: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if w.f != nil {
+		t.Errorf("baseline file is not closed by finish")
+	}
+
+	// Resumed run is aborted before finish, the file
+	// is closed by the cleanup, keeping both runs warnings.
+	w, err = newBaselineWriter(filename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.report(repo, []warning{{checker: "missing file", message: "no LICENSE"}}); err != nil {
+		t.Fatal(err)
+	}
+	l := &linter{tempDir: t.TempDir(), baselineWriter: w}
+	l.cleanup()
+	if w.f != nil {
+		t.Errorf("baseline file is not closed by cleanup")
+	}
+	if err := w.close(); err != nil {
+		t.Errorf("second close: %v", err)
+	}
+
+	res, err := readResults(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []resultEntry{
+		{repo: "github.com/o/a", checker: "misspell", text: "README.md:1: teh"},
+		{repo: "github.com/o/a", checker: "missing file", text: "no LICENSE"},
+	}
+	if !reflect.DeepEqual(res.entries, want) {
+		t.Errorf("baseline entries:\nhave: %q\nwant: %q", res.entries, want)
+	}
+}
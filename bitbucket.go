@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// bitbucketProvider fetches repositories using Bitbucket Server REST API 1.0.
+//
+// Repository owner is a project key. Personal repositories
+// can be accessed with "~username" project key.
+//
+// Bitbucket Server has no stars, languages and push dates,
+// so related filters have no effect for it.
+type bitbucketProvider struct {
+	api restClient
+}
+
+func newBitbucketProvider(ctx context.Context, client *http.Client, baseURL, token string) *bitbucketProvider {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return &bitbucketProvider{
+		api: restClient{
+			ctx:     ctx,
+			client:  client,
+			baseURL: baseURL,
+			header:  header,
+		},
+	}
+}
+
+type bitbucketRepo struct {
+	Slug     string    `json:"slug"`
+	Archived bool      `json:"archived"`
+	Origin   *struct{} `json:"origin"`
+
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+
+	Links struct {
+		Clone []struct {
+			Href string `json:"href"`
+			Name string `json:"name"`
+		} `json:"clone"`
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+// bitbucketPage is a paged API response.
+type bitbucketPage struct {
+	IsLastPage    bool `json:"isLastPage"`
+	NextPageStart int  `json:"nextPageStart"`
+}
+
+func (p *bitbucketProvider) getRepo(owner, name string) (*repository, error) {
+	var repo bitbucketRepo
+	path := "/projects/" + url.PathEscape(owner) + "/repos/" + url.PathEscape(name)
+	if _, err := p.api.getJSON(path, &repo); err != nil {
+		return nil, err
+	}
+	return p.convertRepo(&repo), nil
+}
+
+func (p *bitbucketProvider) listRepos(owner string) ([]*repository, error) {
+	var repos []*repository
+	start := 0
+	for {
+		var page struct {
+			bitbucketPage
+			Values []bitbucketRepo `json:"values"`
+		}
+		path := "/projects/" + url.PathEscape(owner) + "/repos?limit=100&start=" + strconv.Itoa(start)
+		if _, err := p.api.getJSON(path, &page); err != nil {
+			return nil, err
+		}
+		for i := range page.Values {
+			repos = append(repos, p.convertRepo(&page.Values[i]))
+		}
+		if page.IsLastPage {
+			break
+		}
+		start = page.NextPageStart
+	}
+	return repos, nil
+}
+
+func (p *bitbucketProvider) getTree(repo *repository) ([]treeEntry, error) {
+	// Files API doesn't report blob hashes.
+	var entries []treeEntry
+	start := 0
+	for {
+		var page struct {
+			bitbucketPage
+			Values []string `json:"values"`
+		}
+		path := p.repoPath(repo) + "/files?limit=1000&start=" + strconv.Itoa(start) + p.atQuery(repo, "&")
+		if _, err := p.api.getJSON(path, &page); err != nil {
+			return nil, err
+		}
+		for _, filename := range page.Values {
+			entries = append(entries, treeEntry{path: filename})
+		}
+		if page.IsLastPage {
+			break
+		}
+		start = page.NextPageStart
+	}
+	return entries, nil
+}
+
+func (p *bitbucketProvider) getBlob(repo *repository, entry treeEntry) (string, error) {
+	data, _, err := p.api.get(p.repoPath(repo) + "/raw/" + pathEscape(entry.path) + p.atQuery(repo, "?"))
+	return string(data), err
+}
+
+// atQuery returns a query parameter that selects the repo ref.
+// If ref is empty, the default branch is used.
+func (p *bitbucketProvider) atQuery(repo *repository, sep string) string {
+	if repo.ref == "" {
+		return ""
+	}
+	return sep + "at=" + url.QueryEscape(repo.ref)
+}
+
+func (p *bitbucketProvider) repoPath(repo *repository) string {
+	return "/projects/" + url.PathEscape(repo.owner) + "/repos/" + url.PathEscape(repo.name)
+}
+
+func (p *bitbucketProvider) convertRepo(r *bitbucketRepo) *repository {
+	repo := &repository{
+		owner:    r.Project.Key,
+		name:     r.Slug,
+		stars:    -1,
+		fork:     r.Origin != nil,
+		archived: r.Archived,
+	}
+	if len(r.Links.Self) != 0 {
+		repo.webURL = r.Links.Self[0].Href
+	}
+	for _, link := range r.Links.Clone {
+		if link.Name == "http" {
+			repo.cloneURL = link.Href
+		}
+	}
+	return repo
+}
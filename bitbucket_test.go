@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func newTestBitbucketProvider(t *testing.T, api fakeAPI) *bitbucketProvider {
+	return newBitbucketProvider(context.Background(), http.DefaultClient, api.start(t)+"/rest/api/1.0", "secret")
+}
+
+func TestBitbucketListRepos(t *testing.T) {
+	api := fakeAPI{}
+	api["/rest/api/1.0/projects/PRJ/repos"] = func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("missing Authorization header")
+		}
+		switch start := r.URL.Query().Get("start"); start {
+		case "0":
+			fmt.Fprint(w, `{"isLastPage": false, "nextPageStart": 1, "values": [{"slug": "a",
+				"project": {"key": "PRJ"},
+				"links": {"self": [{"href": "https://bb.example/projects/PRJ/repos/a/browse"}],
+					"clone": [{"name": "ssh", "href": "ssh://bb.example/prj/a.git"},
+						{"name": "http", "href": "https://bb.example/scm/prj/a.git"}]}}]}`)
+		case "1":
+			fmt.Fprint(w, `{"isLastPage": true, "values": [{"slug": "b", "project": {"key": "PRJ"},
+				"archived": true, "origin": {}}]}`)
+		default:
+			t.Errorf("unexpected start %q", start)
+		}
+	}
+
+	p := newTestBitbucketProvider(t, api)
+	repos, err := p.listRepos("PRJ")
+	if err != nil {
+		t.Fatalf("listRepos: %v", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("listRepos: got %d repos, want 2", len(repos))
+	}
+	want := repository{
+		owner:    "PRJ",
+		name:     "a",
+		webURL:   "https://bb.example/projects/PRJ/repos/a/browse",
+		cloneURL: "https://bb.example/scm/prj/a.git",
+		stars:    -1,
+	}
+	if !reflect.DeepEqual(*repos[0], want) {
+		t.Errorf("listRepos: got %+v, want %+v", *repos[0], want)
+	}
+	if !repos[1].fork || !repos[1].archived {
+		t.Errorf("listRepos: %s should be an archived fork", repos[1].fullName())
+	}
+}
+
+func TestBitbucketTreeAndBlob(t *testing.T) {
+	api := fakeAPI{}
+	api["/rest/api/1.0/projects/PRJ/repos/a/files"] = func(w http.ResponseWriter, r *http.Request) {
+		if at := r.URL.Query().Get("at"); at != "refs/heads/dev" {
+			t.Errorf("files: got %q at, want refs/heads/dev", at)
+		}
+		if r.URL.Query().Get("start") == "0" {
+			fmt.Fprint(w, `{"isLastPage": false, "nextPageStart": 1, "values": ["docs/READ ME.md"]}`)
+			return
+		}
+		fmt.Fprint(w, `{"isLastPage": true, "values": ["LICENSE"]}`)
+	}
+	api["/rest/api/1.0/projects/PRJ/repos/a/raw/docs/READ%20ME.md"] = func(w http.ResponseWriter, r *http.Request) {
+		if at := r.URL.Query().Get("at"); at != "refs/heads/dev" {
+			t.Errorf("raw: got %q at, want refs/heads/dev", at)
+		}
+		fmt.Fprint(w, "# Title")
+	}
+
+	p := newTestBitbucketProvider(t, api)
+	repo := &repository{owner: "PRJ", name: "a", ref: "refs/heads/dev"}
+	entries, err := p.getTree(repo)
+	if err != nil {
+		t.Fatalf("getTree: %v", err)
+	}
+	wantEntries := []treeEntry{{path: "docs/READ ME.md"}, {path: "LICENSE"}}
+	if !reflect.DeepEqual(entries, wantEntries) {
+		t.Errorf("getTree: got %+v, want %+v", entries, wantEntries)
+	}
+
+	contents, err := p.getBlob(repo, entries[0])
+	if err != nil || contents != "# Title" {
+		t.Errorf("getBlob: got %q, %v", contents, err)
+	}
+}
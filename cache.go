@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// diskCache is a persistent cache that survives between runs.
+//
+// Blobs are stored by their git hashes, so they never become stale.
+// API responses are stored with their ETags and are revalidated
+// with conditional requests.
+//
+// It's safe to use diskCache from multiple goroutines.
+type diskCache struct {
+	dir string
+}
+
+func newDiskCache(dir string) (*diskCache, error) {
+	for _, sub := range []string{"blobs", "http"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &diskCache{dir: dir}, nil
+}
+
+func (c *diskCache) blobPath(sha string) string {
+	return filepath.Join(c.dir, "blobs", sha)
+}
+
+// getBlob returns a blob contents by its hash.
+func (c *diskCache) getBlob(sha string) (string, bool) {
+	data, err := ioutil.ReadFile(c.blobPath(sha))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// putBlob stores a blob contents by its hash.
+func (c *diskCache) putBlob(sha, contents string) error {
+	return writeFileAtomic(c.blobPath(sha), []byte(contents))
+}
+
+// cachedResponse is an API response that can be revalidated with its ETag.
+type cachedResponse struct {
+	ETag   string      `json:"etag"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// responsePath returns a req response cache filename.
+// Authorization is a part of the key, since different
+// tokens can see different sets of repositories.
+func (c *diskCache) responsePath(req *http.Request) string {
+	h := sha256.New()
+	h.Write([]byte(req.URL.String()))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Header.Get("Authorization")))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Header.Get("Private-Token")))
+	return filepath.Join(c.dir, "http", hex.EncodeToString(h.Sum(nil)))
+}
+
+func (c *diskCache) getResponse(req *http.Request) (*cachedResponse, bool) {
+	data, err := ioutil.ReadFile(c.responsePath(req))
+	if err != nil {
+		return nil, false
+	}
+	var resp cachedResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, false
+	}
+	return &resp, true
+}
+
+func (c *diskCache) putResponse(req *http.Request, resp *cachedResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.responsePath(req), data)
+}
+
+// cachedSource is a repoSource that looks up blobs
+// in the cache before fetching them from the source.
+type cachedSource struct {
+	repoSource
+	cache *diskCache
+}
+
+func (s *cachedSource) getBlob(repo *repository, entry treeEntry) (string, error) {
+	if entry.sha == "" {
+		return s.repoSource.getBlob(repo, entry)
+	}
+	if contents, ok := s.cache.getBlob(entry.sha); ok {
+		return contents, nil
+	}
+	contents, err := s.repoSource.getBlob(repo, entry)
+	if err != nil {
+		return "", err
+	}
+	if err := s.cache.putBlob(entry.sha, contents); err != nil {
+		return "", err
+	}
+	return contents, nil
+}
+
+// etagTransport is an http.RoundTripper that makes conditional
+// GET requests for the cached responses. Not modified responses
+// are replaced with the cached ones.
+//
+// GitHub doesn't count "304 Not Modified" responses against
+// the rate limit, so unchanged listings are fetched for free.
+type etagTransport struct {
+	base  http.RoundTripper
+	cache *diskCache
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		return t.base.RoundTrip(req)
+	}
+
+	cached, ok := t.cache.getResponse(req)
+	if ok {
+		// RoundTrip should not modify the request, so it's cloned.
+		req = req.WithContext(req.Context())
+		req.Header = cloneHeader(req.Header)
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		// Fresh headers like rate limits override the cached ones.
+		header := cloneHeader(cached.Header)
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.Header = header
+		resp.Body = ioutil.NopCloser(bytes.NewReader(cached.Body))
+		resp.ContentLength = int64(len(cached.Body))
+		return resp, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	err = t.cache.putResponse(req, &cachedResponse{
+		ETag:   etag,
+		Header: resp.Header,
+		Body:   body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func cloneHeader(h http.Header) http.Header {
+	clone := make(http.Header, len(h))
+	for k, v := range h {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
+}
+
+// writeFileAtomic is like ioutil.WriteFile, but the file
+// is replaced atomically, so it can't be left half-written.
+func writeFileAtomic(filename string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), ".repolint-tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEtagTransport(t *testing.T) {
+	var conditional []string
+	remaining := 50
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remaining--
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(remaining))
+		etag := `"` + r.Header.Get("Authorization") + `"`
+		if r.Header.Get("If-None-Match") != "" {
+			conditional = append(conditional, r.Header.Get("Authorization"))
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"token": %q}`, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	cache, err := newDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &etagTransport{base: http.DefaultTransport, cache: cache}}
+	get := func(token string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest("GET", srv.URL+"/repos", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", token)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	body := func(resp *http.Response) string {
+		t.Helper()
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	if got := body(get("token a")); got != `{"token": "token a"}` {
+		t.Fatalf("first response: got %q", got)
+	}
+
+	// Not modified response is replayed from the cache,
+	// but with the fresh rate limit headers.
+	resp := get("token a")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("cached response: got %s status, want 200 OK", resp.Status)
+	}
+	if got := resp.Header.Get("X-RateLimit-Remaining"); got != "48" {
+		t.Errorf("cached response: got %q remaining requests, want 48", got)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("cached response: got %q content type", got)
+	}
+	if got := body(resp); got != `{"token": "token a"}` {
+		t.Errorf("cached response: got %q body", got)
+	}
+
+	// Other tokens don't share the cached response.
+	if got := body(get("token b")); got != `{"token": "token b"}` {
+		t.Errorf("other token response: got %q", got)
+	}
+	if len(conditional) != 1 || conditional[0] != "token a" {
+		t.Errorf("got conditional requests for %q, want only token a", conditional)
+	}
+}
+
+// countingSource is a repoSource that counts getBlob calls.
+type countingSource struct {
+	blobs int
+}
+
+func (s *countingSource) getTree(repo *repository) ([]treeEntry, error) {
+	return nil, nil
+}
+
+func (s *countingSource) getBlob(repo *repository, entry treeEntry) (string, error) {
+	s.blobs++
+	return "contents of " + entry.path, nil
+}
+
+func TestCachedSource(t *testing.T) {
+	dir := t.TempDir()
+	repo := &repository{owner: "o", name: "r"}
+	entry := treeEntry{path: "README.md", sha: "0123abcd"}
+
+	// Blobs are reused by their hashes in the same run and between runs.
+	for run := 0; run < 2; run++ {
+		cache, err := newDiskCache(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		src := &countingSource{}
+		s := &cachedSource{repoSource: src, cache: cache}
+		for i := 0; i < 2; i++ {
+			contents, err := s.getBlob(repo, entry)
+			if err != nil || contents != "contents of README.md" {
+				t.Fatalf("run %d: getBlob: got %q, %v", run, contents, err)
+			}
+		}
+		if want := 1 - run; src.blobs != want {
+			t.Errorf("run %d: got %d blob fetches, want %d", run, src.blobs, want)
+		}
+
+		// Blobs without hashes are not cached.
+		s.getBlob(repo, treeEntry{path: "LICENSE"})
+		s.getBlob(repo, treeEntry{path: "LICENSE"})
+		if want := 1 - run + 2; src.blobs != want {
+			t.Errorf("run %d: got %d blob fetches, want %d", run, src.blobs, want)
+		}
+	}
+}
@@ -2,28 +2,46 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"os/exec"
+	"path"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/golangci/misspell"
 	"github.com/gomarkdown/markdown/ast"
 	"github.com/gomarkdown/markdown/parser"
-	"github.com/google/go-github/github"
 )
 
 type fileChecker interface {
-	Reset(*github.Repository)
+	Reset(*repository)
 	PushFile(*repoFile)
-	CheckFiles() []string
+	CheckFiles() []warning
+}
+
+// checkerDocs maps checker names to their short descriptions.
+var checkerDocs = map[string]string{
+	"missing file":     "Repository has no root README or LICENSE file",
+	"broken link":      "Documentation contains a link that can't be followed",
+	"relative link":    "Documentation links to a missing repository file or heading",
+	"misspell":         "Documentation contains a commonly misspelled English word",
+	"var name typo":    "Documentation refers to a misspelled environment variable",
+	"unwanted file":    "Repository contains an editor or OS temporary file",
+	"sloppy copyright": "License contains a template copyright holder",
+	"acronym":          "Documentation contains a lowercase acronym",
+	"code snippet":     "README code block language marker is missing or is an alias",
+	"readme badge":     "README has no CI build status badge",
+	"travis lint":      "Travis CI config uses a deprecated command",
 }
 
 type checkerBase struct {
 	files []*repoFile
-	repo  *github.Repository
+	repo  *repository
 }
 
-func (c *checkerBase) Reset(repo *github.Repository) {
+func (c *checkerBase) Reset(repo *repository) {
 	c.files = c.files[:0]
 	c.repo = repo
 }
@@ -36,22 +54,6 @@ func (c *checkerBase) acceptFile(f *repoFile) {
 	c.files = append(c.files, f)
 }
 
-func (c *checkerBase) tempFilenames() []string {
-	names := make([]string, len(c.files))
-	for i, f := range c.files {
-		names[i] = f.tempName
-	}
-	return names
-}
-
-func (c *checkerBase) filenameReplacer() *strings.Replacer {
-	oldnew := make([]string, 0, len(c.files)*2)
-	for _, f := range c.files {
-		oldnew = append(oldnew, f.tempName, f.origName)
-	}
-	return strings.NewReplacer(oldnew...)
-}
-
 var (
 	docFileRE         = regexp.MustCompile(`^(?:README|CONTRIBUTING|TODO).*`)
 	rootLicenseFileRE = regexp.MustCompile(`(?i)^(?:licen[sc]e|copying)(?:[.-].+)?$`)
@@ -72,7 +74,7 @@ type missingFileChecker struct {
 	seenLicense bool
 }
 
-func (c *missingFileChecker) Reset(repo *github.Repository) {
+func (c *missingFileChecker) Reset(repo *repository) {
 	c.checkerBase.Reset(repo)
 	c.seenReadme = false
 	c.seenLicense = false
@@ -87,87 +89,250 @@ func (c *missingFileChecker) PushFile(f *repoFile) {
 	}
 }
 
-func (c *missingFileChecker) CheckFiles() (warnings []string) {
+func (c *missingFileChecker) CheckFiles() (warnings []warning) {
 	if !c.seenReadme {
-		warnings = append(warnings, "missing root README file")
+		warnings = append(warnings, warning{
+			message:  "missing root README file",
+			severity: severityWarning,
+		})
 	}
 	if !c.seenLicense {
-		warnings = append(warnings, "missing root LICENSE file")
+		warnings = append(warnings, warning{
+			message:  "missing root LICENSE file",
+			severity: severityWarning,
+		})
 	}
 	return warnings
 }
 
-type misspellChecker struct{ checkerBase }
+type misspellChecker struct {
+	checkerBase
+	replacer *misspell.Replacer
+}
+
+var misspellReplacers = struct {
+	sync.Mutex
+	m map[string]*misspell.Replacer
+}{m: make(map[string]*misspell.Replacer)}
+
+// newMisspellChecker returns a checker that uses the misspell dictionary
+// with the locale-specific spellings. Locale is either "US", "UK" or empty
+// for a neutral variety of English. Ignored words are never reported.
+func newMisspellChecker(locale string, ignore []string) *misspellChecker {
+	// Dictionary compilation is expensive, but the replacers
+	// are immutable, so they're shared between the checkers.
+	key := locale + "\x00" + strings.Join(ignore, "\x00")
+	misspellReplacers.Lock()
+	defer misspellReplacers.Unlock()
+	r := misspellReplacers.m[key]
+	if r == nil {
+		r = &misspell.Replacer{Replacements: misspell.DictMain}
+		switch locale {
+		case "US":
+			r.AddRuleList(misspell.DictAmerican)
+		case "UK":
+			r.AddRuleList(misspell.DictBritish)
+		}
+		if len(ignore) != 0 {
+			words := make([]string, len(ignore))
+			for i, word := range ignore {
+				words[i] = strings.ToLower(word)
+			}
+			r.RemoveRule(words)
+		}
+		r.Compile()
+		misspellReplacers.m[key] = r
+	}
+	return &misspellChecker{replacer: r}
+}
 
 func (c *misspellChecker) PushFile(f *repoFile) {
 	if isDocumentationFile(f.baseName) {
-		f.require.localCopy = true
+		f.require.contents = true
 		c.acceptFile(f)
 	}
 }
 
-func (c *misspellChecker) CheckFiles() (warnings []string) {
-	args := []string{"-error", "true"}
-	args = append(args, c.tempFilenames()...)
-	out, err := exec.Command("misspell", args...).CombinedOutput()
-	if err != nil {
-		replacer := c.filenameReplacer()
-		lines := strings.Split(string(out), "\n")
-		for _, l := range lines {
-			if l == "" {
-				continue
-			}
-			warnings = append(warnings, replacer.Replace(l))
+func (c *misspellChecker) CheckFiles() (warnings []warning) {
+	for _, f := range c.files {
+		_, diffs := c.replacer.Replace(f.contents)
+		for _, d := range diffs {
+			warnings = append(warnings, warning{
+				file:       f.origName,
+				line:       d.Line,
+				col:        d.Column + 1, // misspell columns are 0-based
+				message:    fmt.Sprintf(`"%s" is a misspelling of "%s"`, d.Original, d.Corrected),
+				severity:   severityWarning,
+				original:   d.Original,
+				suggestion: d.Corrected,
+			})
 		}
 	}
 	return warnings
 }
 
-type brokenLinkChecker struct{ checkerBase }
+type brokenLinkChecker struct {
+	checkerBase
+	links *linkChecker
+
+	// exclude matches links that are not checked.
+	// If nil, all links are checked.
+	exclude *regexp.Regexp
+}
+
+const (
+	defaultBrokenLinkExclude   = `/release|/download|localhost|127\.[01]\.[01]\.[01]|example\.com`
+	defaultBrokenLinkTimeout   = 30 * time.Second
+	defaultBrokenLinkHostLimit = 2
+)
+
+func newBrokenLinkChecker(exclude string, timeout time.Duration, hostLimit int) *brokenLinkChecker {
+	c := &brokenLinkChecker{links: sharedLinkChecker(timeout, hostLimit)}
+	if exclude != "" {
+		c.exclude = regexp.MustCompile(exclude)
+	}
+	return c
+}
 
 func (c *brokenLinkChecker) PushFile(f *repoFile) {
 	if isDocumentationFile(f.baseName) {
-		f.require.localCopy = true
+		f.require.contents = true
 		c.acceptFile(f)
 	}
 }
 
-func (c *brokenLinkChecker) CheckFiles() (warnings []string) {
-	args := []string{"-t", "30", "-x", `/release|/download|localhost|127\.[01]\.[01]\.[01]|example\.com`}
-	args = append(args, c.tempFilenames()...)
-	out, err := exec.Command("liche", args...).CombinedOutput()
-	if err != nil {
-		replacer := c.filenameReplacer()
-		lines := strings.Split(string(out), "\n")
-		var filename string
-		for i := 0; i < len(lines); i++ {
-			l := lines[i]
-			if l == "" {
+func (c *brokenLinkChecker) CheckFiles() (warnings []warning) {
+	type fileLink struct {
+		file *repoFile
+		docLink
+	}
+	var links []fileLink
+	unique := make(map[string]bool)
+	for _, f := range c.files {
+		for _, link := range extractLinks(f.origName, f.contents) {
+			if c.exclude != nil && c.exclude.MatchString(link.url) {
 				continue
 			}
-			if l[0] != '\t' {
-				filename = replacer.Replace(l)
+			links = append(links, fileLink{file: f, docLink: link})
+			unique[link.url] = true
+		}
+	}
+
+	// Links are checked concurrently, the results are cached.
+	var wg sync.WaitGroup
+	for u := range unique {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			c.links.check(context.Background(), u)
+		}(u)
+	}
+	wg.Wait()
+
+	for _, link := range links {
+		result := c.links.check(context.Background(), link.url)
+		if result.status == linkOK {
+			continue
+		}
+		line, col := offsetPosition(link.file.contents, link.offset)
+		w := warning{
+			file:     link.file.origName,
+			line:     line,
+			col:      col,
+			message:  link.url + ": " + result.String(),
+			severity: severityError,
+		}
+		if result.status == linkTimeout {
+			// Slow servers are not necessarily broken.
+			w.severity = severityWarning
+		}
+		warnings = append(warnings, w)
+	}
+	return warnings
+}
+
+type relativeLinkChecker struct {
+	checkerBase
+
+	// paths are all repository file and directory paths.
+	paths map[string]bool
+
+	// targets are linked Markdown files which anchors are checked.
+	targets map[string]*repoFile
+}
+
+func (c *relativeLinkChecker) Reset(repo *repository) {
+	c.checkerBase.Reset(repo)
+	c.targets = make(map[string]*repoFile)
+}
+
+func (c *relativeLinkChecker) SetTree(paths []string) {
+	c.paths = map[string]bool{".": true}
+	for _, p := range paths {
+		for ; p != "."; p = path.Dir(p) {
+			c.paths[p] = true
+		}
+	}
+}
+
+func (c *relativeLinkChecker) PushFile(f *repoFile) {
+	if isDocumentationFile(f.baseName) {
+		f.require.contents = true
+		c.acceptFile(f)
+	}
+}
+
+func (c *relativeLinkChecker) PushTargets(files map[string]*repoFile) {
+	for _, f := range c.files {
+		c.targets[f.origName] = f
+	}
+	for _, f := range c.files {
+		for _, link := range extractRelativeLinks(f.origName, f.contents) {
+			target, anchor, ok := resolveRelativeLink(f.origName, link.url)
+			if !ok || target == "" || anchor == "" || !isMarkdownFile(target) {
 				continue
 			}
-			if !strings.Contains(l, "ERROR") {
+			if t := files[target]; t != nil && c.targets[target] == nil {
+				t.require.contents = true
+				c.targets[target] = t
+			}
+		}
+	}
+}
+
+func (c *relativeLinkChecker) CheckFiles() (warnings []warning) {
+	anchors := make(map[string]map[string]bool)
+
+	for _, f := range c.files {
+		for _, link := range extractRelativeLinks(f.origName, f.contents) {
+			target, anchor, ok := resolveRelativeLink(f.origName, link.url)
+			if !ok {
 				continue
 			}
-			// Next line contains error info.
-			url := strings.TrimLeft(l, "\t ERROR")
-			i++
-			l = strings.TrimSpace(lines[i])
-			if l == "Timeout" {
-				// Reporting timeouts can lead to a lots of false positives.
-				// Better to skip them silently.
+			line, col := offsetPosition(f.contents, link.offset)
+			w := warning{file: f.origName, line: line, col: col}
+			if target == "" {
+				target = f.origName
+			} else if !c.paths[target] {
+				w.message = link.url + ": no such file"
+				w.severity = severityError
+				warnings = append(warnings, w)
 				continue
 			}
-			if strings.Contains(l, "no such file") || strings.Contains(l, "root directory is not specified") {
-				// Not interested in file lookups, since we're
-				// not doing real git cloning.
+
+			// Excluded and vendored files are not fetched.
+			doc := c.targets[target]
+			if anchor == "" || doc == nil || !isMarkdownFile(target) {
 				continue
 			}
-			w := fmt.Sprintf("%s: %s: %s", filename, url, l)
-			warnings = append(warnings, w)
+			if anchors[target] == nil {
+				anchors[target] = markdownAnchors(doc.contents)
+			}
+			if !hasAnchor(anchors[target], anchor) {
+				w.message = link.url + ": no such anchor"
+				w.severity = severityWarning
+				warnings = append(warnings, w)
+			}
 		}
 	}
 	return warnings
@@ -178,35 +343,43 @@ type unwantedFileChecker struct {
 	patterns map[string]*regexp.Regexp
 }
 
-func newUnwantedFileChecker() *unwantedFileChecker {
-	return &unwantedFileChecker{
-		patterns: map[string]*regexp.Regexp{
-			// -> foo.txt.swp
-			"Vim swap": regexp.MustCompile(`^.*\.swp$`),
-			// -> #foo.txt#
-			"Emacs autosave": regexp.MustCompile(`^#.*#$`),
-			// -> foo.txt~
-			"Emacs backup": regexp.MustCompile(`^.*~$`),
-			// -> .#foo.txt
-			"Emacs lock file": regexp.MustCompile(`^\.#.*$`),
-			// -> .DS_STORE
-			"Mac OS sys file": regexp.MustCompile(`^\.DS_STORE$`),
-			// -> Thumbs.db
-			"Windows sys file": regexp.MustCompile(`^Thumbs\.db$`),
-			// -> foo.txt.save
-			"Nano emergency file": regexp.MustCompile(`^.*\.save(?:\.\d)?$`),
-		},
-	}
-}
-
-func (c *unwantedFileChecker) CheckFiles() (warnings []string) {
+// defaultUnwantedFilePatterns maps unwanted file kinds to their base name patterns.
+var defaultUnwantedFilePatterns = map[string]string{
+	// -> foo.txt.swp
+	"Vim swap": `^.*\.swp$`,
+	// -> #foo.txt#
+	"Emacs autosave": `^#.*#$`,
+	// -> foo.txt~
+	"Emacs backup": `^.*~$`,
+	// -> .#foo.txt
+	"Emacs lock file": `^\.#.*$`,
+	// -> .DS_STORE
+	"Mac OS sys file": `^\.DS_STORE$`,
+	// -> Thumbs.db
+	"Windows sys file": `^Thumbs\.db$`,
+	// -> foo.txt.save
+	"Nano emergency file": `^.*\.save(?:\.\d)?$`,
+}
+
+func newUnwantedFileChecker(patterns map[string]string) *unwantedFileChecker {
+	c := &unwantedFileChecker{patterns: make(map[string]*regexp.Regexp, len(patterns))}
+	for kind, pattern := range patterns {
+		c.patterns[kind] = regexp.MustCompile(pattern)
+	}
+	return c
+}
+
+func (c *unwantedFileChecker) CheckFiles() (warnings []warning) {
 	for _, f := range c.files {
 		for kind, pat := range c.patterns {
 			if !pat.MatchString(f.baseName) {
 				continue
 			}
-			w := fmt.Sprintf("remove %s file: %s", kind, f.origName)
-			warnings = append(warnings, w)
+			warnings = append(warnings, warning{
+				file:     f.origName,
+				message:  fmt.Sprintf("remove %s file", kind),
+				severity: severityWarning,
+			})
 		}
 	}
 	return warnings
@@ -236,12 +409,20 @@ func (c *sloppyCopyrightChecker) PushFile(f *repoFile) {
 	}
 }
 
-func (c *sloppyCopyrightChecker) CheckFiles() (warnings []string) {
+func (c *sloppyCopyrightChecker) CheckFiles() (warnings []warning) {
 	for _, f := range c.files {
-		if c.copyrightRE.MatchString(f.contents) {
-			w := fmt.Sprintf("%s: license contains sloppy copyright", f.origName)
-			warnings = append(warnings, w)
+		loc := c.copyrightRE.FindStringIndex(f.contents)
+		if loc == nil {
+			continue
 		}
+		line, col := offsetPosition(f.contents, loc[0])
+		warnings = append(warnings, warning{
+			file:     f.origName,
+			line:     line,
+			col:      col,
+			message:  "license contains sloppy copyright",
+			severity: severityWarning,
+		})
 	}
 	return warnings
 }
@@ -252,32 +433,34 @@ type acronymChecker struct {
 	acronymMap map[string]string
 }
 
-func newAcronymChecker() *acronymChecker {
-	fromTo := map[string]string{
-		// TODO: more of these.
+// defaultAcronyms maps lowercase acronyms to their proper spelling.
+var defaultAcronyms = map[string]string{
+	// TODO: more of these.
 
-		"gnu":  "GNU",
-		"sql":  "SQL",
-		"dsl":  "DSL",
-		"ansi": "ANSI",
-		"bios": "BIOS",
-		"cgi":  "CGI",
-		"ssa":  "SSA",
-		"dpi":  "DPI",
-		"gui":  "GUI",
-		"oop":  "OOP",
-	}
+	"gnu":  "GNU",
+	"sql":  "SQL",
+	"dsl":  "DSL",
+	"ansi": "ANSI",
+	"bios": "BIOS",
+	"cgi":  "CGI",
+	"ssa":  "SSA",
+	"dpi":  "DPI",
+	"gui":  "GUI",
+	"oop":  "OOP",
+}
 
-	parts := make([]string, 0, len(fromTo))
-	for from := range fromTo {
-		parts = append(parts, `(?:^|\s)`+from+`(?:$|\s)`)
+func newAcronymChecker(fromTo map[string]string) *acronymChecker {
+	c := &acronymChecker{acronymMap: fromTo}
+	if len(fromTo) == 0 {
+		return c
 	}
 
-	re := regexp.MustCompile(strings.Join(parts, "|"))
-	return &acronymChecker{
-		acronymMap: fromTo,
-		acronymRE:  re,
+	parts := make([]string, 0, len(fromTo))
+	for _, from := range sortedKeys(fromTo) {
+		parts = append(parts, `(?:^|\s)`+regexp.QuoteMeta(from)+`(?:$|\s)`)
 	}
+	c.acronymRE = regexp.MustCompile(strings.Join(parts, "|"))
+	return c
 }
 
 func (c *acronymChecker) PushFile(f *repoFile) {
@@ -287,15 +470,27 @@ func (c *acronymChecker) PushFile(f *repoFile) {
 	}
 }
 
-func (c *acronymChecker) CheckFiles() (warnings []string) {
+func (c *acronymChecker) CheckFiles() (warnings []warning) {
+	if c.acronymRE == nil {
+		return nil
+	}
 	for _, f := range c.files {
 		lines := strings.Split(f.contents, "\n")
 		for i, l := range lines {
-			for _, m := range c.acronymRE.FindAllString(l, -1) {
+			for _, loc := range c.acronymRE.FindAllStringIndex(l, -1) {
+				m := l[loc[0]:loc[1]]
+				// Match can include surrounding whitespace.
+				col := loc[0] + strings.Index(m, strings.TrimSpace(m)) + 1
 				m = strings.TrimSpace(m)
-				w := fmt.Sprintf("%s:%d: replace %s with %s",
-					f.origName, i+1, m, c.acronymMap[m])
-				warnings = append(warnings, w)
+				warnings = append(warnings, warning{
+					file:       f.origName,
+					line:       i + 1,
+					col:        col,
+					message:    fmt.Sprintf("replace %s with %s", m, c.acronymMap[m]),
+					severity:   severityInfo,
+					original:   m,
+					suggestion: c.acronymMap[m],
+				})
 			}
 		}
 	}
@@ -306,39 +501,50 @@ type varTypoChecker struct {
 	checkerBase
 	varsRE  *regexp.Regexp
 	varsMap map[string]string
+
+	// fixesMap maps a typo match to its corrected form.
+	fixesMap map[string]string
 }
 
-func newVarTypoChecker() *varTypoChecker {
-	typos := map[string]string{
-		// TODO: more of these.
+// defaultVarTypos maps misspelled environment variable names to the correct ones.
+var defaultVarTypos = map[string]string{
+	// TODO: more of these.
 
-		"PAHT": "PATH",
-		"HOEM": "HOME",
+	"PAHT": "PATH",
+	"HOEM": "HOME",
 
-		"GOPAHT": "GOPATH",
+	"GOPAHT": "GOPATH",
 
-		"JAAV_HOME": "JAVA_HOME",
-		"JAVA_HOEM": "JAVA_HOME",
-		"JAVE_HOME": "JAVA_HOME",
+	"JAAV_HOME": "JAVA_HOME",
+	"JAVA_HOEM": "JAVA_HOME",
+	"JAVE_HOME": "JAVA_HOME",
 
-		"CLASSPAHT": "CLASSPATH",
-		"CLASPATH":  "CLASSPATH",
-	}
+	"CLASSPAHT": "CLASSPATH",
+	"CLASPATH":  "CLASSPATH",
+}
 
+func newVarTypoChecker(typos map[string]string) *varTypoChecker {
 	fromTo := make(map[string]string)
-	parts := make([]string, 0, len(fromTo))
-	for typo, corrected := range typos {
-		parts = append(parts, `\$`+typo+`\b`)
+	fixes := make(map[string]string)
+	parts := make([]string, 0, len(typos)*2)
+	for _, typo := range sortedKeys(typos) {
+		corrected := typos[typo]
+		parts = append(parts, `\$`+regexp.QuoteMeta(typo)+`\b`)
 		fromTo[`$`+typo] = corrected
-		parts = append(parts, `\$\{`+typo+`\}`)
+		fixes[`$`+typo] = `$` + corrected
+		parts = append(parts, `\$\{`+regexp.QuoteMeta(typo)+`\}`)
 		fromTo[`${`+typo+`}`] = corrected
+		fixes[`${`+typo+`}`] = `${` + corrected + `}`
 	}
 
-	re := regexp.MustCompile(strings.Join(parts, "|"))
-	return &varTypoChecker{
-		varsMap: fromTo,
-		varsRE:  re,
+	c := &varTypoChecker{
+		varsMap:  fromTo,
+		fixesMap: fixes,
 	}
+	if len(parts) != 0 {
+		c.varsRE = regexp.MustCompile(strings.Join(parts, "|"))
+	}
+	return c
 }
 
 func (c *varTypoChecker) PushFile(f *repoFile) {
@@ -348,14 +554,24 @@ func (c *varTypoChecker) PushFile(f *repoFile) {
 	}
 }
 
-func (c *varTypoChecker) CheckFiles() (warnings []string) {
+func (c *varTypoChecker) CheckFiles() (warnings []warning) {
+	if c.varsRE == nil {
+		return nil
+	}
 	for _, f := range c.files {
 		lines := strings.Split(f.contents, "\n")
 		for i, l := range lines {
-			for _, m := range c.varsRE.FindAllString(l, -1) {
-				w := fmt.Sprintf("%s:%d: %s could be a misspelling of %s",
-					f.origName, i+1, m, c.varsMap[m])
-				warnings = append(warnings, w)
+			for _, loc := range c.varsRE.FindAllStringIndex(l, -1) {
+				m := l[loc[0]:loc[1]]
+				warnings = append(warnings, warning{
+					file:       f.origName,
+					line:       i + 1,
+					col:        loc[0] + 1,
+					message:    fmt.Sprintf("%s could be a misspelling of %s", m, c.varsMap[m]),
+					severity:   severityWarning,
+					original:   m,
+					suggestion: c.fixesMap[m],
+				})
 			}
 		}
 	}
@@ -370,20 +586,29 @@ func (c *travisChecker) PushFile(f *repoFile) {
 	if f.origName == ".travis.yml" {
 		// Since we only check Go things for now, don't require
 		// a file if it's not a Go repository.
-		if c.repo.GetLanguage() == "Go" {
+		if c.repo.language == "Go" {
 			f.require.contents = true
 			c.acceptFile(f)
 		}
 	}
 }
 
-func (c *travisChecker) CheckFiles() (warnings []string) {
+func (c *travisChecker) CheckFiles() (warnings []warning) {
 	if len(c.files) == 0 {
 		return warnings
 	}
-	contents := c.files[0].contents
-	if strings.Contains(contents, "go tool vet") {
-		warnings = append(warnings, "use `go vet` instead of `go tool vet`")
+	f := c.files[0]
+	if i := strings.Index(f.contents, "go tool vet"); i != -1 {
+		line, col := offsetPosition(f.contents, i)
+		warnings = append(warnings, warning{
+			file:       f.origName,
+			line:       line,
+			col:        col,
+			message:    "use `go vet` instead of `go tool vet`",
+			severity:   severityWarning,
+			original:   "go tool vet",
+			suggestion: "go vet",
+		})
 	}
 	return warnings
 }
@@ -391,12 +616,10 @@ func (c *travisChecker) CheckFiles() (warnings []string) {
 type badgeChecker struct {
 	checkerBase
 
-	user string
-
 	seenTravisYML bool
 }
 
-func (c *badgeChecker) Reset(repo *github.Repository) {
+func (c *badgeChecker) Reset(repo *repository) {
 	c.checkerBase.Reset(repo)
 	c.seenTravisYML = false
 }
@@ -411,15 +634,22 @@ func (c *badgeChecker) PushFile(f *repoFile) {
 	}
 }
 
-func (c *badgeChecker) CheckFiles() (warnings []string) {
-	if len(c.files) == 0 || !c.seenTravisYML {
+func (c *badgeChecker) CheckFiles() (warnings []warning) {
+	if len(c.files) == 0 || !c.seenTravisYML || c.repo.owner == "" {
 		return warnings
 	}
 	readme := c.files[0]
-	badgeURL := "https://travis-ci.org/" + c.user + "/" + *c.repo.Name + ".svg?branch=master"
+	badgeURL := "https://travis-ci.org/" + c.repo.fullName() + ".svg"
+	if c.repo.ref != "" {
+		badgeURL += "?branch=" + c.repo.ref
+	}
 	if !strings.Contains(readme.contents, badgeURL) {
 		if urlReachable(badgeURL) {
-			warnings = append(warnings, "could add travis-ci build status badge "+badgeURL)
+			warnings = append(warnings, warning{
+				file:     readme.origName,
+				message:  "could add travis-ci build status badge " + badgeURL,
+				severity: severityInfo,
+			})
 		}
 	}
 	return warnings
@@ -436,41 +666,74 @@ func (c *codeSnippetChecker) PushFile(f *repoFile) {
 	}
 }
 
-func (c *codeSnippetChecker) CheckFiles() (warnings []string) {
+// golangFenceRE matches fenced code block openers with "golang" marker.
+var golangFenceRE = regexp.MustCompile("(?m)^[ \t]*(?:```+|~~~+)[ \t]*(golang)[ \t]*$")
+
+func (c *codeSnippetChecker) CheckFiles() (warnings []warning) {
 	for _, f := range c.files {
 		p := parser.New()
 		id := 1
 		rootNode := p.Parse([]byte(f.contents))
+		var fileWarnings []warning
 		for _, n := range rootNode.GetChildren() {
 			b, ok := n.(*ast.CodeBlock)
 			if !ok {
 				continue
 			}
-			warnings = c.checkCodeBlock(id, warnings, b)
+			fileWarnings = c.checkCodeBlock(f, id, fileWarnings, b)
 			id++
 		}
+
+		// Markdown AST has no positions, so "golang" markers
+		// are matched with the fence lines in the same order.
+		fences := golangFenceRE.FindAllStringSubmatchIndex(f.contents, -1)
+		for i := range fileWarnings {
+			w := &fileWarnings[i]
+			if w.original != "golang" || len(fences) == 0 {
+				continue
+			}
+			w.line, w.col = offsetPosition(f.contents, fences[0][2])
+			fences = fences[1:]
+		}
+		warnings = append(warnings, fileWarnings...)
 	}
 	return warnings
 }
 
-func (c *codeSnippetChecker) checkCodeBlock(id int, warnings []string, b *ast.CodeBlock) []string {
+func (c *codeSnippetChecker) checkCodeBlock(f *repoFile, id int, warnings []warning, b *ast.CodeBlock) []warning {
 	if len(b.Info) != 0 {
 		// Suggest changing an alias to a real name.
 		// TODO(Quasilyte): handle more aliases.
 		//	See https://github.com/github/linguist/blob/master/lib/linguist/languages.yml.
 		if bytes.Equal(b.Info, []byte("golang")) {
-			w := fmt.Sprintf(`block #%d: use "go" marker instead of "golang"`, id)
-			warnings = append(warnings, w)
+			warnings = append(warnings, warning{
+				file:       f.origName,
+				message:    fmt.Sprintf(`block #%d: use "go" marker instead of "golang"`, id),
+				severity:   severityInfo,
+				original:   "golang",
+				suggestion: "go",
+			})
 		}
 		return warnings
 	}
 
 	// Try to suggest language marker, since it's missing.
 
-	if lang := progLangBySources(c.repo.GetLanguage(), b.Literal); lang != "" {
-		w := fmt.Sprintf("block #%d: add %q language marker", id, lang)
-		warnings = append(warnings, w)
+	if lang := progLangBySources(c.repo.language, b.Literal); lang != "" {
+		warnings = append(warnings, warning{
+			file:     f.origName,
+			message:  fmt.Sprintf("block #%d: add %q language marker", id, lang),
+			severity: severityInfo,
+		})
 	}
 
 	return warnings
 }
+
+// offsetPosition converts a byte offset inside s to a 1-based line and column.
+func offsetPosition(s string, offset int) (line, col int) {
+	prefix := s[:offset]
+	line = strings.Count(prefix, "\n") + 1
+	col = offset - strings.LastIndexByte(prefix, '\n')
+	return line, col
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMisspellChecker(t *testing.T) {
+	contents := "We recieve the colour.\n" +
+		"\n" +
+		"  The color of teh sky.\n"
+	tests := []struct {
+		locale string
+		ignore []string
+		want   []string
+	}{
+		{
+			locale: "",
+			want: []string{
+				`README.md:1:4: "recieve" is a misspelling of "receive"`,
+				`README.md:3:16: "teh" is a misspelling of "the"`,
+			},
+		},
+		{
+			locale: "US",
+			want: []string{
+				`README.md:1:4: "recieve" is a misspelling of "receive"`,
+				`README.md:1:16: "colour" is a misspelling of "color"`,
+				`README.md:3:16: "teh" is a misspelling of "the"`,
+			},
+		},
+		{
+			locale: "UK",
+			want: []string{
+				`README.md:1:4: "recieve" is a misspelling of "receive"`,
+				`README.md:3:7: "color" is a misspelling of "colour"`,
+				`README.md:3:16: "teh" is a misspelling of "the"`,
+			},
+		},
+		{
+			locale: "US",
+			ignore: []string{"Colour", "teh"},
+			want: []string{
+				`README.md:1:4: "recieve" is a misspelling of "receive"`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		c := newMisspellChecker(test.locale, test.ignore)
+		c.Reset(&repository{owner: "o", name: "r"})
+		c.PushFile(&repoFile{origName: "main.go", baseName: "main.go", contents: "// teh"})
+		f := &repoFile{origName: "README.md", baseName: "README.md", contents: contents}
+		c.PushFile(f)
+		if !f.require.contents {
+			t.Fatalf("README.md contents are not requested")
+		}
+
+		var have []string
+		for _, w := range c.CheckFiles() {
+			have = append(have, w.String())
+			if w.suggestion == "" || w.original == "" {
+				t.Errorf("%s: no fix suggestion", w.String())
+			}
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("locale=%q ignore=%q:\nhave: %q\nwant: %q",
+				test.locale, test.ignore, have, test.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// checkpoint is a lint run progress that is saved after every
+// checked repository, so interrupted runs can be resumed.
+type checkpoint struct {
+	User string `json:"user"`
+
+	// Done is a list of checked repositories full names.
+	// Names are used instead of indexes, because the repositories
+	// list order can change between runs.
+	Done []string `json:"done"`
+
+	// Failed is a list of repositories that couldn't be checked,
+	// like the ones with a tree fetch error. They're checked again on resume.
+	Failed []string `json:"failed,omitempty"`
+
+	// Warnings is a number of warnings printed so far.
+	Warnings int `json:"warnings"`
+
+	// Requests is a number of API requests made so far.
+	Requests int `json:"requests"`
+
+	done map[string]bool
+}
+
+func newCheckpoint(user string) *checkpoint {
+	return &checkpoint{
+		User: user,
+		Done: []string{},
+		done: make(map[string]bool),
+	}
+}
+
+// loadCheckpoint reads a checkpoint previously saved to the filename.
+func loadCheckpoint(filename string) (*checkpoint, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	cp.done = make(map[string]bool, len(cp.Done))
+	for _, name := range cp.Done {
+		cp.done[name] = true
+	}
+	return &cp, nil
+}
+
+// isDone reports whether repo was already checked.
+func (cp *checkpoint) isDone(repo *repository) bool {
+	return cp.done[repo.fullName()]
+}
+
+// markDone records repo check completion.
+func (cp *checkpoint) markDone(repo *repository, warnings int) {
+	name := repo.fullName()
+	cp.done[name] = true
+	cp.Done = append(cp.Done, name)
+	cp.Warnings += warnings
+	cp.Failed = removeString(cp.Failed, name)
+}
+
+// markFailed records repo check failure.
+// The repo is still pending, so it's checked again on resume.
+func (cp *checkpoint) markFailed(repo *repository) {
+	name := repo.fullName()
+	cp.Failed = append(removeString(cp.Failed, name), name)
+}
+
+// removeString returns list without s elements.
+func removeString(list []string, s string) []string {
+	filtered := list[:0]
+	for _, x := range list {
+		if x != s {
+			filtered = append(filtered, x)
+		}
+	}
+	return filtered
+}
+
+// save writes checkpoint to the filename.
+func (cp *checkpoint) save(filename string) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type checkstyleOutput struct {
+	XMLName xml.Name          `xml:"checkstyle"`
+	Version string            `xml:"version,attr"`
+	Files   []*checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string             `xml:"name,attr"`
+	Errors []*checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr,omitempty"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// checkstyleReporter prints checkstyle XML report with warnings
+// grouped per file. File names are prefixed with the repository location.
+// Repository-level warnings are reported for the repository itself.
+type checkstyleReporter struct {
+	w      io.Writer
+	l      *linter
+	output checkstyleOutput
+}
+
+func (r *checkstyleReporter) report(repo *repository, warnings []warning) error {
+	files := make(map[string]*checkstyleFile)
+	for _, w := range warnings {
+		name := r.l.repoPath(repo)
+		if w.file != "" {
+			name += "/" + w.file
+		}
+		f := files[name]
+		if f == nil {
+			f = &checkstyleFile{Name: name}
+			files[name] = f
+			r.output.Files = append(r.output.Files, f)
+		}
+		f.Errors = append(f.Errors, &checkstyleError{
+			Line:     w.line,
+			Column:   w.col,
+			Severity: w.severity.String(),
+			Message:  w.message,
+			Source:   "repolint." + w.checker,
+		})
+	}
+	return nil
+}
+
+func (r *checkstyleReporter) finish(s *runSummary) error {
+	r.output.Version = "5.0"
+	return writeXML(r.w, &r.output)
+}
+
+// writeXML prints v as an indented XML document.
+func writeXML(w io.Writer, v interface{}) error {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+	return err
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// configFilename is a config file name that is looked up in the
+// working directory and in the linted repositories root.
+const configFilename = ".repolint.yml"
+
+// config is a .repolint.yml file contents.
+type config struct {
+	// Enable and Disable are checker names that are enabled
+	// or disabled on top of the -disable flag.
+	Enable  []string `yaml:"enable"`
+	Disable []string `yaml:"disable"`
+
+	// Include and Exclude are repository file path globs.
+	// If Include is not empty, only matching files are checked.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+
+	// Severity maps checker names to their warnings severity,
+	// overriding the checker defaults.
+	Severity map[string]string `yaml:"severity"`
+
+	// Ignore suppresses warnings by checker and path glob.
+	Ignore []*ignoreRule `yaml:"ignore"`
+
+	// Flags override command-line flags defaults.
+	// Flags that are passed explicitly are not affected.
+	Flags map[string]string `yaml:"flags"`
+
+	Checkers checkersConfig `yaml:"checkers"`
+
+	include    []*regexp.Regexp
+	exclude    []*regexp.Regexp
+	severities map[string]severity
+}
+
+// ignoreRule suppresses matching warnings.
+// Empty fields match any warning.
+type ignoreRule struct {
+	Checker string `yaml:"checker"`
+
+	// Path is a warning file path glob.
+	Path string `yaml:"path"`
+
+	path *regexp.Regexp
+}
+
+// match reports whether w is suppressed by the rule.
+func (r *ignoreRule) match(w *warning) bool {
+	if r.Checker != "" && r.Checker != w.checker {
+		return false
+	}
+	return r.path == nil || r.path.MatchString(w.file)
+}
+
+// checkersConfig is a per-checker options.
+//
+// Map options are merged with the built-in values.
+// An empty value removes the built-in entry.
+type checkersConfig struct {
+	Acronym struct {
+		// Acronyms maps lowercase acronyms to their proper spelling.
+		Acronyms map[string]string `yaml:"acronyms"`
+	} `yaml:"acronym"`
+
+	VarNameTypo struct {
+		// Typos maps misspelled environment variable names to the correct ones.
+		Typos map[string]string `yaml:"typos"`
+	} `yaml:"var name typo"`
+
+	UnwantedFile struct {
+		// Patterns maps file kinds to their base name regexps.
+		Patterns map[string]string `yaml:"patterns"`
+	} `yaml:"unwanted file"`
+
+	Misspell struct {
+		// Locale is "US" or "UK" to report the other locale spellings.
+		// By default, a neutral variety of English is used.
+		Locale string `yaml:"locale"`
+
+		// Ignore is a list of words that are never reported.
+		Ignore []string `yaml:"ignore"`
+	} `yaml:"misspell"`
+
+	BrokenLink struct {
+		// Exclude is a regexp of links that are not checked.
+		// An empty regexp disables the built-in exclusions.
+		Exclude *string `yaml:"exclude"`
+
+		// Timeout is a single request timeout, like "10s".
+		Timeout string `yaml:"timeout"`
+
+		// HostLimit is a max number of concurrent requests per host.
+		HostLimit int `yaml:"hostLimit"`
+
+		timeout time.Duration
+	} `yaml:"broken link"`
+}
+
+// loadConfig reads and validates a config file.
+func loadConfig(filename string) (*config, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return cfg, nil
+}
+
+func parseConfig(data []byte) (*config, error) {
+	cfg := &config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.compile(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// compile checks the config values and compiles its globs.
+func (cfg *config) compile() error {
+	for _, names := range [][]string{cfg.Enable, cfg.Disable} {
+		for _, name := range names {
+			if _, ok := checkerDocs[name]; !ok {
+				return fmt.Errorf("unknown checker %q", name)
+			}
+		}
+	}
+
+	cfg.severities = make(map[string]severity, len(cfg.Severity))
+	for name, level := range cfg.Severity {
+		if _, ok := checkerDocs[name]; !ok {
+			return fmt.Errorf("severity: unknown checker %q", name)
+		}
+		s, err := parseSeverity(level)
+		if err != nil {
+			return fmt.Errorf("severity: %s: %v", name, err)
+		}
+		cfg.severities[name] = s
+	}
+
+	for _, rule := range cfg.Ignore {
+		if rule.Checker == "" && rule.Path == "" {
+			return errors.New("ignore: either checker or path should be set")
+		}
+		if _, ok := checkerDocs[rule.Checker]; !ok && rule.Checker != "" {
+			return fmt.Errorf("ignore: unknown checker %q", rule.Checker)
+		}
+		if rule.Path != "" {
+			rule.path = globRE(rule.Path)
+		}
+	}
+
+	cfg.include = cfg.include[:0]
+	for _, glob := range cfg.Include {
+		cfg.include = append(cfg.include, globRE(glob))
+	}
+	cfg.exclude = cfg.exclude[:0]
+	for _, glob := range cfg.Exclude {
+		cfg.exclude = append(cfg.exclude, globRE(glob))
+	}
+
+	for kind, pattern := range cfg.Checkers.UnwantedFile.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("unwanted file: %s pattern: %v", kind, err)
+		}
+	}
+	switch cfg.Checkers.Misspell.Locale {
+	case "", "US", "UK":
+	default:
+		return fmt.Errorf("misspell: unknown locale %q, expected US or UK", cfg.Checkers.Misspell.Locale)
+	}
+	if re := cfg.Checkers.BrokenLink.Exclude; re != nil {
+		if _, err := regexp.Compile(*re); err != nil {
+			return fmt.Errorf("broken link: exclude: %v", err)
+		}
+	}
+	if timeout := cfg.Checkers.BrokenLink.Timeout; timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("broken link: invalid timeout %q", timeout)
+		}
+		cfg.Checkers.BrokenLink.timeout = d
+	}
+	if cfg.Checkers.BrokenLink.HostLimit < 0 {
+		return errors.New("broken link: hostLimit can't be negative")
+	}
+	return nil
+}
+
+// merge returns a config where other values take precedence over cfg values.
+// Lists, except the Include globs, are concatenated.
+func (cfg *config) merge(other *config) *config {
+	merged := &config{
+		Enable:   append(append([]string(nil), cfg.Enable...), other.Enable...),
+		Disable:  append(append([]string(nil), cfg.Disable...), other.Disable...),
+		Include:  cfg.Include,
+		Exclude:  append(append([]string(nil), cfg.Exclude...), other.Exclude...),
+		Ignore:   append(append([]*ignoreRule(nil), cfg.Ignore...), other.Ignore...),
+		Severity: mergeMaps(cfg.Severity, other.Severity),
+		Flags:    cfg.Flags,
+		Checkers: cfg.Checkers,
+	}
+	if len(other.Include) != 0 {
+		merged.Include = other.Include
+	}
+
+	c, o := &merged.Checkers, &other.Checkers
+	c.Acronym.Acronyms = mergeMaps(c.Acronym.Acronyms, o.Acronym.Acronyms)
+	c.VarNameTypo.Typos = mergeMaps(c.VarNameTypo.Typos, o.VarNameTypo.Typos)
+	c.UnwantedFile.Patterns = mergeMaps(c.UnwantedFile.Patterns, o.UnwantedFile.Patterns)
+	if o.Misspell.Locale != "" {
+		c.Misspell.Locale = o.Misspell.Locale
+	}
+	c.Misspell.Ignore = append(append([]string(nil), c.Misspell.Ignore...), o.Misspell.Ignore...)
+	if o.BrokenLink.Exclude != nil {
+		c.BrokenLink.Exclude = o.BrokenLink.Exclude
+	}
+	if o.BrokenLink.Timeout != "" {
+		c.BrokenLink.Timeout = o.BrokenLink.Timeout
+	}
+	if o.BrokenLink.HostLimit != 0 {
+		c.BrokenLink.HostLimit = o.BrokenLink.HostLimit
+	}
+
+	// Both configs are already validated.
+	merged.compile()
+	return merged
+}
+
+// enabled reports whether checker is enabled by the config.
+// If the config doesn't mention the checker, enabled is returned.
+func (cfg *config) enabled(checker string, enabled bool) bool {
+	for _, name := range cfg.Disable {
+		if name == checker {
+			enabled = false
+		}
+	}
+	for _, name := range cfg.Enable {
+		if name == checker {
+			enabled = true
+		}
+	}
+	return enabled
+}
+
+// ignored reports whether w is suppressed by the config ignore list.
+func (cfg *config) ignored(w *warning) bool {
+	for _, rule := range cfg.Ignore {
+		if rule.match(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptFile reports whether a repository file should be checked.
+func (cfg *config) acceptFile(filename string) bool {
+	for _, re := range cfg.exclude {
+		if re.MatchString(filename) {
+			return false
+		}
+	}
+	if len(cfg.include) == 0 {
+		return true
+	}
+	for _, re := range cfg.include {
+		if re.MatchString(filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeMaps returns a map with both maps entries.
+// Empty values are kept, so they can remove built-in entries later.
+func mergeMaps(base, override map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// mergeOptions returns the built-in values updated with the config values.
+func mergeOptions(builtin, options map[string]string) map[string]string {
+	merged := mergeMaps(builtin, options)
+	for k, v := range merged {
+		if v == "" {
+			delete(merged, k)
+		}
+	}
+	return merged
+}
+
+// globRE converts a file path glob to a regexp.
+//
+// "*" matches any sequence of non-separator characters, "**" also
+// matches separators and "?" matches a single non-separator character.
+// Globs without a separator are matched against the file base name,
+// so "*.swp" matches "a/b.swp". Globs ending with a separator
+// match everything inside a directory.
+func globRE(glob string) *regexp.Regexp {
+	var buf strings.Builder
+	if strings.HasSuffix(glob, "/") {
+		glob += "**"
+	}
+	if !strings.Contains(strings.TrimSuffix(glob, "/**"), "/") {
+		buf.WriteString(`(?:^|/)`)
+	} else {
+		buf.WriteString(`^`)
+	}
+	glob = strings.TrimPrefix(glob, "/")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			buf.WriteString(`(?:.*/)?`)
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			buf.WriteString(`.*`)
+			i++
+		case glob[i] == '*':
+			buf.WriteString(`[^/]*`)
+		case glob[i] == '?':
+			buf.WriteString(`[^/]`)
+		default:
+			buf.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	buf.WriteString(`$`)
+	return regexp.MustCompile(buf.String())
+}
+
+// sortedKeys returns m keys in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGlobRE(t *testing.T) {
+	tests := []struct {
+		glob    string
+		match   []string
+		noMatch []string
+	}{
+		// Globs without a separator match the base name.
+		{"*.swp", []string{".a.swp", "a/b.swp", "a/b/.c.swp"}, []string{"a.swp/b", "a.swpx"}},
+		{"README.?d", []string{"README.md", "docs/README.md"}, []string{"README.mdx", "README.d"}},
+		// Globs with a separator are matched against the whole path.
+		{"docs/*.md", []string{"docs/a.md"}, []string{"a.md", "docs/a/b.md", "x/docs/a.md"}},
+		{"/docs/*.md", []string{"docs/a.md"}, []string{"x/docs/a.md"}},
+		// "**/" matches any number of directories, including none.
+		{"**/testdata/*", []string{"testdata/a", "a/b/testdata/c"}, []string{"testdata/a/b", "a/testdatax/b"}},
+		{"docs/**/*.md", []string{"docs/a.md", "docs/a/b/c.md"}, []string{"a.md", "docs/a.txt"}},
+		// Trailing separator matches everything inside a directory.
+		{"vendor/", []string{"vendor/a.go", "vendor/a/b.go", "x/vendor/a.go"}, []string{"vendor", "vendorx/a.go"}},
+		{"third_party/vendor/", []string{"third_party/vendor/a/b.go"}, []string{"x/third_party/vendor/a.go"}},
+		// Regexp meta characters are quoted.
+		{"a+b.(md)", []string{"a+b.(md)"}, []string{"aab.(md)", "a+bx(md)"}},
+	}
+
+	for _, test := range tests {
+		re := globRE(test.glob)
+		for _, s := range test.match {
+			if !re.MatchString(s) {
+				t.Errorf("%q doesn't match %q (regexp %s)", test.glob, s, re)
+			}
+		}
+		for _, s := range test.noMatch {
+			if re.MatchString(s) {
+				t.Errorf("%q matches %q (regexp %s)", test.glob, s, re)
+			}
+		}
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []string{
+		"unknown: 1\n",
+		"enable: [misspell]\ninclude: [a]\nexlude: [b]\n",
+		"checkers:\n  misspell:\n    locales: [US]\n",
+		"checkers:\n  misspell:\n    locale: EN\n",
+		"checkers:\n  acronym:\n    acronym: {a: A}\n",
+		"flags: [a, b]\n",
+		"enable: [no such checker]\n",
+		"checkers:\n  unwanted file:\n    patterns: {junk: '('}\n",
+		"checkers:\n  broken link:\n    exclude: '['\n",
+	}
+	for _, data := range tests {
+		if _, err := parseConfig([]byte(data)); err == nil {
+			t.Errorf("no error for config:\n%s", data)
+		}
+	}
+
+	cfg, err := parseConfig([]byte("enable: [misspell]\nexclude: [vendor/]\n"))
+	if err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+	if !cfg.enabled("misspell", false) || cfg.acceptFile("vendor/a.md") || !cfg.acceptFile("a.md") {
+		t.Errorf("valid config is not applied: %+v", cfg)
+	}
+}
+
+func TestConfigMerge(t *testing.T) {
+	global, err := parseConfig([]byte(`
+disable: [misspell]
+include: ["*.md", "*.yml"]
+exclude: [vendor/]
+flags:
+  minStars: "10"
+checkers:
+  acronym:
+    acronyms: {github: GitHub, gitlab: GitLab}
+  broken link:
+    exclude: example\.com
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := parseConfig([]byte(`
+enable: [misspell]
+include: ["*.md"]
+exclude: [testdata/]
+flags:
+  minStars: "100"
+checkers:
+  acronym:
+    acronyms: {gitlab: "", json: JSON}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := global.merge(repo)
+
+	// Repository configs can't change the command-line flags.
+	if !reflect.DeepEqual(cfg.Flags, global.Flags) {
+		t.Errorf("merged flags: got %v, want %v", cfg.Flags, global.Flags)
+	}
+	if !cfg.enabled("misspell", false) {
+		t.Errorf("misspell is not enabled by the repository config")
+	}
+	if !reflect.DeepEqual(cfg.Include, repo.Include) {
+		t.Errorf("merged include: got %q, want %q", cfg.Include, repo.Include)
+	}
+	if cfg.acceptFile("a.yml") || cfg.acceptFile("vendor/a.md") || cfg.acceptFile("testdata/a.md") {
+		t.Errorf("excluded file is accepted")
+	}
+	if !cfg.acceptFile("docs/a.md") {
+		t.Errorf("included file is not accepted")
+	}
+	wantAcronyms := map[string]string{"github": "GitHub", "gitlab": "", "json": "JSON"}
+	if !reflect.DeepEqual(cfg.Checkers.Acronym.Acronyms, wantAcronyms) {
+		t.Errorf("merged acronyms: got %v, want %v", cfg.Checkers.Acronym.Acronyms, wantAcronyms)
+	}
+	if e := cfg.Checkers.BrokenLink.Exclude; e == nil || *e != `example\.com` {
+		t.Errorf("broken link exclude is not inherited")
+	}
+
+	// The merged configs are not modified.
+	if global.enabled("misspell", false) || len(global.Exclude) != 1 {
+		t.Errorf("global config is modified: %+v", global)
+	}
+	if _, ok := global.Checkers.Acronym.Acronyms["json"]; ok {
+		t.Errorf("global config acronyms are modified")
+	}
+}
+
+func TestLoadConfigFlags(t *testing.T) {
+	commandLine := flag.CommandLine
+	defer func() { flag.CommandLine = commandLine }()
+	flag.CommandLine = flag.NewFlagSet("repolint", flag.ContinueOnError)
+
+	var minStars int
+	var lang, output string
+	flag.IntVar(&minStars, "minStars", 0, "")
+	flag.StringVar(&lang, "lang", "", "")
+	flag.StringVar(&output, "output", "text", "")
+	if err := flag.CommandLine.Parse([]string{"-minStars=5"}); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), configFilename)
+	data := "flags:\n  minStars: \"10\"\n  lang: Go\n"
+	if err := ioutil.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l := &linter{configPath: filename}
+	if err := l.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Explicit flags take precedence over the config flags.
+	if minStars != 5 {
+		t.Errorf("minStars: got %d, want 5", minStars)
+	}
+	if lang != "Go" {
+		t.Errorf("lang: got %q, want Go", lang)
+	}
+	if output != "text" {
+		t.Errorf("output: got %q, want text", output)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// diffGroup is a single repository checker warnings comparison.
+type diffGroup struct {
+	repo    string
+	checker string
+
+	fixed []resultEntry
+	added []resultEntry
+	open  []resultEntry
+}
+
+// runDiff implements "diff" command that compares two lint results.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: repolint diff [flags] old-results new-results\n")
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "text",
+		`output format: text or json`)
+	list := fs.Bool("list", false,
+		`whether to print every fixed and new warning in text format`)
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("expected 2 results files")
+	}
+	oldResults, err := readResults(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	newResults, err := readResults(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	groups := diffResults(oldResults, newResults)
+	switch *format {
+	case "text":
+		return printDiffText(os.Stdout, groups, *list)
+	case "json":
+		return printDiffJSON(os.Stdout, groups)
+	default:
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+}
+
+// diffRepoKey returns a repository key that is used to match
+// the same repository in both results.
+//
+// Only the repository name is used, because older results
+// have no owner and host prefix. Results are expected to
+// be collected for the same user or organization.
+func diffRepoKey(repo string) string {
+	return repo[strings.LastIndexByte(repo, '/')+1:]
+}
+
+// diffCheckedKeys returns a set of the checked repositories keys,
+// or nil if the results don't record the checked repositories.
+func diffCheckedKeys(res *results) map[string]bool {
+	if len(res.checked) == 0 {
+		return nil
+	}
+	keys := make(map[string]bool, len(res.checked))
+	for name := range res.checked {
+		keys[diffRepoKey(name)] = true
+	}
+	return keys
+}
+
+// diffResults compares the old and new results warnings
+// grouped by repository and checker.
+//
+// Only repositories that are checked by both runs are compared,
+// so a repository that was skipped or failed in the new run
+// doesn't have its warnings reported as fixed.
+func diffResults(oldRes, newRes *results) []*diffGroup {
+	oldChecked, newChecked := diffCheckedKeys(oldRes), diffCheckedKeys(newRes)
+	compared := func(entries []resultEntry) []resultEntry {
+		var list []resultEntry
+		for _, e := range entries {
+			key := diffRepoKey(e.repo)
+			if oldChecked != nil && !oldChecked[key] {
+				continue
+			}
+			if newChecked != nil && !newChecked[key] {
+				continue
+			}
+			list = append(list, e)
+		}
+		return list
+	}
+	oldResults, newResults := compared(oldRes.entries), compared(newRes.entries)
+
+	groups := make(map[string]*diffGroup)
+	group := func(e resultEntry) *diffGroup {
+		key := diffRepoKey(e.repo) + "\x00" + e.checker
+		g := groups[key]
+		if g == nil {
+			g = &diffGroup{checker: e.checker}
+			groups[key] = g
+		}
+		return g
+	}
+
+	// Repositories are printed with their longest known location.
+	repoNames := make(map[string]string)
+	for _, results := range [][]resultEntry{oldResults, newResults} {
+		for _, e := range results {
+			key := diffRepoKey(e.repo)
+			if len(e.repo) > len(repoNames[key]) {
+				repoNames[key] = e.repo
+			}
+		}
+	}
+	fingerprint := func(e resultEntry) string {
+		e.repo = diffRepoKey(e.repo)
+		return e.fingerprint()
+	}
+
+	remaining := make(map[string][]resultEntry)
+	for _, e := range oldResults {
+		group(e)
+		remaining[fingerprint(e)] = append(remaining[fingerprint(e)], e)
+	}
+	for _, e := range newResults {
+		g := group(e)
+		key := fingerprint(e)
+		if len(remaining[key]) != 0 {
+			remaining[key] = remaining[key][1:]
+			g.open = append(g.open, e)
+			continue
+		}
+		g.added = append(g.added, e)
+	}
+	for _, e := range oldResults {
+		key := fingerprint(e)
+		if len(remaining[key]) != 0 {
+			g := group(e)
+			g.fixed = append(g.fixed, remaining[key][0])
+			remaining[key] = remaining[key][1:]
+		}
+	}
+
+	list := make([]*diffGroup, 0, len(groups))
+	for key, g := range groups {
+		g.repo = repoNames[key[:strings.IndexByte(key, 0)]]
+		list = append(list, g)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		ki, kj := diffRepoKey(list[i].repo), diffRepoKey(list[j].repo)
+		if ki != kj {
+			return ki < kj
+		}
+		return list[i].checker < list[j].checker
+	})
+	return list
+}
+
+func printDiffText(w io.Writer, groups []*diffGroup, list bool) error {
+	var fixed, added, open int
+	repo := ""
+	for _, g := range groups {
+		if g.repo != repo {
+			repo = g.repo
+			fmt.Fprintf(w, "%s:\n", repo)
+		}
+		fmt.Fprintf(w, "\t%s: %d fixed, %d new, %d open\n",
+			g.checker, len(g.fixed), len(g.added), len(g.open))
+		if list {
+			for _, e := range g.fixed {
+				fmt.Fprintf(w, "\t\tfixed: %s\n", e.text)
+			}
+			for _, e := range g.added {
+				fmt.Fprintf(w, "\t\tnew: %s\n", e.text)
+			}
+		}
+		fixed += len(g.fixed)
+		added += len(g.added)
+		open += len(g.open)
+	}
+	_, err := fmt.Fprintf(w, "total: %d fixed, %d new, %d open\n", fixed, added, open)
+	return err
+}
+
+func printDiffJSON(w io.Writer, groups []*diffGroup) error {
+	type jsonGroup struct {
+		Repo    string   `json:"repo"`
+		Checker string   `json:"checker"`
+		Fixed   []string `json:"fixed"`
+		New     []string `json:"new"`
+		Open    []string `json:"open"`
+	}
+	texts := func(entries []resultEntry) []string {
+		list := make([]string, len(entries))
+		for i, e := range entries {
+			list[i] = e.text
+		}
+		return list
+	}
+	out := make([]jsonGroup, len(groups))
+	for i, g := range groups {
+		out[i] = jsonGroup{
+			Repo:    g.repo,
+			Checker: g.checker,
+			Fixed:   texts(g.fixed),
+			New:     texts(g.added),
+			Open:    texts(g.open),
+		}
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestDiffResultsChecked(t *testing.T) {
+	oldResults := textResults([]byte(`	checking o/a (1/3, made 1 requests so far) ...
+github.com/o/a: misspell: README.md:1:0: "existance" is a misspelling of "existence"
+	checking o/b (2/3, made 2 requests so far) ...
+github.com/o/b: misspell: README.md:1:0: "existance" is a misspelling of "existence"
+	checking o/c (3/3, made 3 requests so far) ...
+github.com/o/c: misspell: README.md:1:0: "existance" is a misspelling of "existence"
+`))
+	// o/b is fixed, o/c failed to be checked, o/d is new.
+	newResults := recordsResults([]*jsonRecord{
+		{Type: "warning", Repo: "o/a", URL: "https://github.com/o/a", Checker: "misspell",
+			File: "README.md", Line: 3, Message: `"existance" is a misspelling of "existence"`},
+		{Type: "warning", Repo: "o/d", URL: "https://github.com/o/d", Checker: "misspell",
+			File: "README.md", Line: 1, Message: `"existance" is a misspelling of "existence"`},
+		{Type: "summary", Checked: []string{"o/a", "o/b", "o/d"}},
+	})
+
+	have := make(map[string][3]int)
+	for _, g := range diffResults(oldResults, newResults) {
+		have[g.repo] = [3]int{len(g.fixed), len(g.added), len(g.open)}
+	}
+	want := map[string][3]int{
+		"github.com/o/a": {0, 0, 1},
+		"github.com/o/b": {1, 0, 0},
+	}
+	if len(have) != len(want) {
+		t.Errorf("got %d groups, want %d: %v", len(have), len(want), have)
+	}
+	for repo, counts := range want {
+		if have[repo] != counts {
+			t.Errorf("%s: got %v fixed/new/open, want %v", repo, have[repo], counts)
+		}
+	}
+}
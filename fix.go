@@ -0,0 +1,378 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fixer is implemented by checkers that can fix their warnings.
+type fixer interface {
+	// Fix returns edits that fix w.
+	// Returns nil if w can't be fixed automatically.
+	Fix(w *warning) []fileEdit
+}
+
+// fileEdit is a single repository file change.
+// It's either a text replacement or a file deletion.
+type fileEdit struct {
+	file string
+
+	// remove is true if the file should be deleted.
+	remove bool
+
+	// original text at 1-based line:col is replaced by replacement.
+	line        int
+	col         int
+	original    string
+	replacement string
+}
+
+// suggestionEdits returns an edit that replaces the warning
+// original text with its suggestion.
+func suggestionEdits(w *warning) []fileEdit {
+	if w.suggestion == "" || w.original == "" || w.line == 0 || w.col == 0 {
+		return nil
+	}
+	return []fileEdit{{
+		file:        w.file,
+		line:        w.line,
+		col:         w.col,
+		original:    w.original,
+		replacement: w.suggestion,
+	}}
+}
+
+func (c *misspellChecker) Fix(w *warning) []fileEdit    { return suggestionEdits(w) }
+func (c *acronymChecker) Fix(w *warning) []fileEdit     { return suggestionEdits(w) }
+func (c *varTypoChecker) Fix(w *warning) []fileEdit     { return suggestionEdits(w) }
+func (c *travisChecker) Fix(w *warning) []fileEdit      { return suggestionEdits(w) }
+func (c *codeSnippetChecker) Fix(w *warning) []fileEdit { return suggestionEdits(w) }
+func (c *unwantedFileChecker) Fix(w *warning) []fileEdit {
+	return []fileEdit{{file: w.file, remove: true}}
+}
+
+// fileChange is a fixed repository file.
+type fileChange struct {
+	file string
+
+	// removed is true if the file is deleted.
+	// newContents is empty in that case.
+	removed bool
+
+	oldContents string
+	newContents string
+
+	// mode is a git file mode that is preserved by the change.
+	mode string
+
+	// fixed is a list of warnings that are fixed by this change.
+	fixed []warning
+}
+
+// fixRepo returns file changes that fix the repo warnings.
+// Edits that don't match the file contents are skipped.
+func (l *linter) fixRepo(repo *repository, files []*repoFile, checkers map[string]fileChecker, warnings []warning) []*fileChange {
+	filesByName := make(map[string]*repoFile, len(files))
+	for _, f := range files {
+		filesByName[f.origName] = f
+	}
+
+	changes := make(map[string]*fileChange)
+	var order []string
+	edits := make(map[string][]fileEdit)
+	fixedBy := make(map[string][]warning)
+	for _, w := range warnings {
+		fx, ok := checkers[w.checker].(fixer)
+		if !ok {
+			continue
+		}
+		for _, e := range fx.Fix(&w) {
+			f := filesByName[e.file]
+			if f == nil {
+				continue
+			}
+			ch := changes[e.file]
+			if ch == nil {
+				ch = &fileChange{file: e.file, oldContents: l.fileContents(repo, f), mode: f.mode}
+				changes[e.file] = ch
+				order = append(order, e.file)
+			}
+			if e.remove {
+				ch.removed = true
+			}
+			edits[e.file] = append(edits[e.file], e)
+			fixedBy[e.file] = append(fixedBy[e.file], w)
+		}
+	}
+
+	result := make([]*fileChange, 0, len(order))
+	for _, name := range order {
+		ch := changes[name]
+		if ch.removed {
+			ch.fixed = fixedBy[name]
+			result = append(result, ch)
+			continue
+		}
+		newContents, applied := applyEdits(ch.oldContents, edits[name])
+		if newContents == ch.oldContents {
+			continue
+		}
+		ch.newContents = newContents
+		for i, ok := range applied {
+			if ok {
+				ch.fixed = append(ch.fixed, fixedBy[name][i])
+			}
+		}
+		result = append(result, ch)
+	}
+	return result
+}
+
+// fileContents returns the repository file contents,
+// reusing a local copy if it's available.
+func (l *linter) fileContents(repo *repository, f *repoFile) string {
+	if f.require.contents {
+		return f.contents
+	}
+	if f.tempName != "" {
+		data, err := ioutil.ReadFile(f.tempName)
+		if err == nil {
+			return string(data)
+		}
+	}
+	data, err := l.getContents(repo, f)
+	if err != nil {
+		log.Printf("\terror: %v", err)
+	}
+	return data
+}
+
+// applyEdits applies replacement edits to s.
+// For every edit, it reports whether it was applied.
+// Edits that don't match s or overlap with other edits are not applied.
+func applyEdits(s string, edits []fileEdit) (string, []bool) {
+	type span struct {
+		index int
+		start int
+		end   int
+	}
+
+	lineStarts := []int{0}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	applied := make([]bool, len(edits))
+	var spans []span
+	for i, e := range edits {
+		if e.line < 1 || e.line > len(lineStarts) || e.col < 1 {
+			continue
+		}
+		start := lineStarts[e.line-1] + e.col - 1
+		end := start + len(e.original)
+		if end > len(s) || s[start:end] != e.original {
+			continue
+		}
+		spans = append(spans, span{index: i, start: start, end: end})
+	}
+
+	// Apply from the end, so offsets stay valid.
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].start > spans[j].start
+	})
+	limit := len(s)
+	for _, sp := range spans {
+		if sp.end > limit {
+			continue
+		}
+		s = s[:sp.start] + edits[sp.index].replacement + s[sp.end:]
+		applied[sp.index] = true
+		limit = sp.start
+	}
+	return s, applied
+}
+
+// writeFix writes repo changes as a unified diff to the patches directory.
+// In -dir mode, changes are applied to the working tree instead.
+func (l *linter) writeFix(repo *repository, changes []*fileChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if src, ok := l.source.(*dirSource); ok {
+		for _, ch := range changes {
+			filename := src.localPath(ch.file)
+			var err error
+			if ch.removed {
+				err = os.Remove(filename)
+			} else {
+				err = ioutil.WriteFile(filename, []byte(ch.newContents), 0644)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		log.Printf("\tfixed %d files in %s", len(changes), l.localRepo())
+		return nil
+	}
+
+	var patch strings.Builder
+	for _, ch := range changes {
+		patch.WriteString(ch.diff())
+	}
+	if err := os.MkdirAll(l.patchDir, 0755); err != nil {
+		return err
+	}
+	name := strings.Replace(repo.fullName(), "/", "-", -1) + ".patch"
+	filename := filepath.Join(l.patchDir, name)
+	if err := ioutil.WriteFile(filename, []byte(patch.String()), 0644); err != nil {
+		return err
+	}
+	log.Printf("\twrote %s patch to %s", repo.fullName(), filename)
+	return nil
+}
+
+// openPullRequest opens a pull request with the repo changes.
+func (l *linter) openPullRequest(repo *repository, changes []*fileChange) error {
+	if len(changes) == 0 {
+		log.Printf("\tnothing to fix in %s", repo.fullName())
+		return nil
+	}
+	p := l.provider.(*githubProvider)
+	prURL, err := p.openPullRequest(&pullRequest{
+		repo:    repo,
+		changes: changes,
+		branch:  l.prBranch,
+		dryRun:  l.dryRun,
+	})
+	if err != nil || l.dryRun {
+		return err
+	}
+	log.Printf("\topened %s", prURL)
+	return nil
+}
+
+// fileMode returns the changed file git mode.
+// Regular file mode is assumed if the source doesn't report modes.
+func (ch *fileChange) fileMode() string {
+	if ch.mode == "" {
+		return "100644"
+	}
+	return ch.mode
+}
+
+// diff returns the change as a git-style unified diff.
+func (ch *fileChange) diff() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "diff --git a/%s b/%s\n", ch.file, ch.file)
+	if ch.removed {
+		fmt.Fprintf(&buf, "deleted file mode %s\n", ch.fileMode())
+		if strings.IndexByte(ch.oldContents, 0) != -1 {
+			fmt.Fprintf(&buf, "Binary files a/%s and /dev/null differ\n", ch.file)
+			return buf.String()
+		}
+		if ch.oldContents == "" {
+			return buf.String()
+		}
+		fmt.Fprintf(&buf, "--- a/%s\n+++ /dev/null\n", ch.file)
+		lines := splitLines(ch.oldContents)
+		fmt.Fprintf(&buf, "@@ -1,%d +0,0 @@\n", len(lines))
+		for _, line := range lines {
+			writeDiffLine(&buf, '-', line)
+		}
+		return buf.String()
+	}
+
+	fmt.Fprintf(&buf, "--- a/%s\n+++ b/%s\n", ch.file, ch.file)
+	buf.WriteString(unifiedHunks(splitLines(ch.oldContents), splitLines(ch.newContents)))
+	return buf.String()
+}
+
+// unifiedHunks returns diff hunks for the old and new lines.
+//
+// Fixes only replace text inside lines, so both versions
+// have the same number of lines. Otherwise, a whole file
+// replacement hunk is returned.
+func unifiedHunks(old, new []string) string {
+	const context = 3
+
+	var buf strings.Builder
+	if len(old) != len(new) {
+		fmt.Fprintf(&buf, "@@ -1,%d +1,%d @@\n", len(old), len(new))
+		for _, line := range old {
+			writeDiffLine(&buf, '-', line)
+		}
+		for _, line := range new {
+			writeDiffLine(&buf, '+', line)
+		}
+		return buf.String()
+	}
+
+	var changed []int
+	for i := range old {
+		if old[i] != new[i] {
+			changed = append(changed, i)
+		}
+	}
+	for len(changed) != 0 {
+		// Merge changes which contexts overlap into a single hunk.
+		last := 0
+		for last+1 < len(changed) && changed[last+1]-changed[last] <= 2*context {
+			last++
+		}
+		from := changed[0] - context
+		if from < 0 {
+			from = 0
+		}
+		to := changed[last] + context + 1
+		if to > len(old) {
+			to = len(old)
+		}
+		fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", from+1, to-from, from+1, to-from)
+		for i := from; i < to; {
+			if old[i] == new[i] {
+				writeDiffLine(&buf, ' ', old[i])
+				i++
+				continue
+			}
+			// Adjacent changed lines are printed as a single block.
+			j := i
+			for j < to && old[j] != new[j] {
+				j++
+			}
+			for _, line := range old[i:j] {
+				writeDiffLine(&buf, '-', line)
+			}
+			for _, line := range new[i:j] {
+				writeDiffLine(&buf, '+', line)
+			}
+			i = j
+		}
+		changed = changed[last+1:]
+	}
+	return buf.String()
+}
+
+// splitLines splits s into lines that keep their "\n" terminators.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func writeDiffLine(buf *strings.Builder, op byte, line string) {
+	buf.WriteByte(op)
+	buf.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		buf.WriteString("\n\\ No newline at end of file\n")
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestApplyEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		edits   []fileEdit
+		want    string
+		applied []bool
+	}{
+		{
+			name:    "single",
+			s:       "teh cat\n",
+			edits:   []fileEdit{{line: 1, col: 1, original: "teh", replacement: "the"}},
+			want:    "the cat\n",
+			applied: []bool{true},
+		},
+		{
+			name: "same line",
+			s:    "a teh b teh\nteh\n",
+			edits: []fileEdit{
+				{line: 1, col: 3, original: "teh", replacement: "the"},
+				{line: 1, col: 9, original: "teh", replacement: "the"},
+				{line: 2, col: 1, original: "teh", replacement: "the"},
+			},
+			want:    "a the b the\nthe\n",
+			applied: []bool{true, true, true},
+		},
+		{
+			name: "mismatched",
+			s:    "foo bar\n",
+			edits: []fileEdit{
+				{line: 1, col: 1, original: "bar", replacement: "baz"},
+				{line: 1, col: 5, original: "bar", replacement: "baz"},
+			},
+			want:    "foo baz\n",
+			applied: []bool{false, true},
+		},
+		{
+			name: "out of range",
+			s:    "foo\n",
+			edits: []fileEdit{
+				{line: 3, col: 1, original: "foo", replacement: "bar"},
+				{line: 1, col: 0, original: "foo", replacement: "bar"},
+				{line: 1, col: 3, original: "oo\nx", replacement: "bar"},
+			},
+			want:    "foo\n",
+			applied: []bool{false, false, false},
+		},
+		{
+			name: "overlapping",
+			s:    "hello world\n",
+			edits: []fileEdit{
+				{line: 1, col: 1, original: "hello", replacement: "bye"},
+				{line: 1, col: 3, original: "llo w", replacement: "y, w"},
+			},
+			want:    "hey, world\n",
+			applied: []bool{false, true},
+		},
+	}
+
+	for _, test := range tests {
+		have, applied := applyEdits(test.s, test.edits)
+		if have != test.want {
+			t.Errorf("%s: result mismatch:\nhave: %q\nwant: %q", test.name, have, test.want)
+		}
+		if !reflect.DeepEqual(applied, test.applied) {
+			t.Errorf("%s: applied mismatch:\nhave: %v\nwant: %v", test.name, applied, test.applied)
+		}
+	}
+}
+
+// numberedLines returns n lines with their 1-based numbers as text.
+// Lines with the specified 0-based indexes get a "!" suffix.
+func numberedLines(n int, changed ...int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = strconv.Itoa(i+1) + "\n"
+	}
+	for _, i := range changed {
+		lines[i] = strconv.Itoa(i+1) + "!\n"
+	}
+	return lines
+}
+
+func TestUnifiedHunks(t *testing.T) {
+	tests := []struct {
+		name string
+		old  []string
+		new  []string
+		want string
+	}{
+		{
+			name: "single line",
+			old:  numberedLines(10),
+			new:  numberedLines(10, 4),
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+5!\n 6\n 7\n 8\n",
+		},
+		{
+			name: "adjacent lines",
+			old:  numberedLines(3),
+			new:  numberedLines(3, 0, 1),
+			want: "@@ -1,3 +1,3 @@\n-1\n-2\n+1!\n+2!\n 3\n",
+		},
+		{
+			// Contexts touch, changes are merged into a single hunk.
+			name: "merged at 2*context",
+			old:  numberedLines(12),
+			new:  numberedLines(12, 0, 6),
+			want: "@@ -1,10 +1,10 @@\n-1\n+1!\n 2\n 3\n 4\n 5\n 6\n-7\n+7!\n 8\n 9\n 10\n",
+		},
+		{
+			name: "split after 2*context",
+			old:  numberedLines(12),
+			new:  numberedLines(12, 0, 7),
+			want: "@@ -1,4 +1,4 @@\n-1\n+1!\n 2\n 3\n 4\n" +
+				"@@ -5,7 +5,7 @@\n 5\n 6\n 7\n-8\n+8!\n 9\n 10\n 11\n",
+		},
+		{
+			name: "no newline at end of file",
+			old:  []string{"a\n", "b"},
+			new:  []string{"a\n", "c"},
+			want: "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			// Line counts differ, the whole file is replaced.
+			name: "fallback",
+			old:  []string{"a\n", "b\n"},
+			new:  []string{"a\n"},
+			want: "@@ -1,2 +1,1 @@\n-a\n-b\n+a\n",
+		},
+		{
+			name: "unchanged",
+			old:  numberedLines(2),
+			new:  numberedLines(2),
+			want: "",
+		},
+	}
+
+	for _, test := range tests {
+		have := unifiedHunks(test.old, test.new)
+		if have != test.want {
+			t.Errorf("%s: hunks mismatch:\nhave:\n%s\nwant:\n%s", test.name, have, test.want)
+		}
+	}
+}
+
+func TestFileChangeDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		ch   fileChange
+		want string
+	}{
+		{
+			name: "modified",
+			ch:   fileChange{file: "README.md", oldContents: "teh\n", newContents: "the\n"},
+			want: "diff --git a/README.md b/README.md\n" +
+				"--- a/README.md\n+++ b/README.md\n" +
+				"@@ -1,1 +1,1 @@\n-teh\n+the\n",
+		},
+		{
+			name: "removed",
+			ch:   fileChange{file: ".a.swp", removed: true, oldContents: "a\nb"},
+			want: "diff --git a/.a.swp b/.a.swp\n" +
+				"deleted file mode 100644\n" +
+				"--- a/.a.swp\n+++ /dev/null\n" +
+				"@@ -1,2 +0,0 @@\n-a\n-b\n\\ No newline at end of file\n",
+		},
+		{
+			name: "removed binary",
+			ch:   fileChange{file: "a.exe", removed: true, oldContents: "MZ\x00\x01"},
+			want: "diff --git a/a.exe b/a.exe\n" +
+				"deleted file mode 100644\n" +
+				"Binary files a/a.exe and /dev/null differ\n",
+		},
+		{
+			name: "removed empty",
+			ch:   fileChange{file: ".DS_Store", removed: true},
+			want: "diff --git a/.DS_Store b/.DS_Store\n" +
+				"deleted file mode 100644\n",
+		},
+	}
+
+	for _, test := range tests {
+		have := test.ch.diff()
+		if have != test.want {
+			t.Errorf("%s: diff mismatch:\nhave:\n%s\nwant:\n%s", test.name, have, test.want)
+		}
+	}
+}
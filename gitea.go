@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// giteaProvider fetches repositories using Gitea API v1.
+//
+// Repository owner is a user or an organization name.
+type giteaProvider struct {
+	api restClient
+}
+
+func newGiteaProvider(ctx context.Context, client *http.Client, baseURL, token string) *giteaProvider {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "token "+token)
+	}
+	return &giteaProvider{
+		api: restClient{
+			ctx:     ctx,
+			client:  client,
+			baseURL: baseURL,
+			header:  header,
+		},
+	}
+}
+
+type giteaRepo struct {
+	Name          string    `json:"name"`
+	HTMLURL       string    `json:"html_url"`
+	CloneURL      string    `json:"clone_url"`
+	DefaultBranch string    `json:"default_branch"`
+	StarsCount    int       `json:"stars_count"`
+	Fork          bool      `json:"fork"`
+	Archived      bool      `json:"archived"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	Owner struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+type giteaTree struct {
+	Tree []struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+		SHA  string `json:"sha"`
+	} `json:"tree"`
+	Truncated bool `json:"truncated"`
+}
+
+type giteaBlob struct {
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+}
+
+func (p *giteaProvider) getRepo(owner, name string) (*repository, error) {
+	var repo giteaRepo
+	if _, err := p.api.getJSON("/repos/"+url.PathEscape(owner)+"/"+url.PathEscape(name), &repo); err != nil {
+		return nil, err
+	}
+	return p.convertRepo(&repo), nil
+}
+
+func (p *giteaProvider) listRepos(owner string) ([]*repository, error) {
+	list, err := p.listOwnerRepos("/orgs/" + url.PathEscape(owner) + "/repos")
+	if isNotFound(err) {
+		list, err = p.listOwnerRepos("/users/" + url.PathEscape(owner) + "/repos")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]*repository, 0, len(list))
+	for i := range list {
+		repos = append(repos, p.convertRepo(&list[i]))
+	}
+	return repos, nil
+}
+
+func (p *giteaProvider) listOwnerRepos(path string) ([]giteaRepo, error) {
+	var repos []giteaRepo
+	for page := 1; ; page++ {
+		var batch []giteaRepo
+		if _, err := p.api.getJSON(path+"?limit=50&page="+strconv.Itoa(page), &batch); err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		repos = append(repos, batch...)
+	}
+	return repos, nil
+}
+
+func (p *giteaProvider) getTree(repo *repository) ([]treeEntry, error) {
+	var entries []treeEntry
+	for page := 1; ; page++ {
+		var tree giteaTree
+		path := p.repoPath(repo) + "/git/trees/" + url.PathEscape(repo.ref) +
+			"?recursive=true&per_page=1000&page=" + strconv.Itoa(page)
+		if _, err := p.api.getJSON(path, &tree); err != nil {
+			return nil, err
+		}
+		for _, entry := range tree.Tree {
+			if entry.Type != "blob" {
+				continue
+			}
+			entries = append(entries, treeEntry{path: entry.Path, sha: entry.SHA})
+		}
+		if !tree.Truncated || len(tree.Tree) == 0 {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func (p *giteaProvider) getBlob(repo *repository, entry treeEntry) (string, error) {
+	var blob giteaBlob
+	if _, err := p.api.getJSON(p.repoPath(repo)+"/git/blobs/"+entry.sha, &blob); err != nil {
+		return "", err
+	}
+	if blob.Encoding != "base64" {
+		return "", fmt.Errorf("unexpected %q blob encoding", blob.Encoding)
+	}
+	data, err := base64.StdEncoding.DecodeString(blob.Content)
+	return string(data), err
+}
+
+// repoLanguage fetches the repository major language with a separate request,
+// since the Repositories API doesn't report it.
+func (p *giteaProvider) repoLanguage(repo *repository) (string, error) {
+	var shares map[string]float64
+	if _, err := p.api.getJSON(p.repoPath(repo)+"/languages", &shares); err != nil {
+		return "", err
+	}
+	return majorLanguage(shares), nil
+}
+
+func (p *giteaProvider) hasTag(repo *repository, tag string) (bool, error) {
+	_, _, err := p.api.get(p.repoPath(repo) + "/tags/" + url.PathEscape(tag))
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (p *giteaProvider) repoPath(repo *repository) string {
+	return "/repos/" + url.PathEscape(repo.owner) + "/" + url.PathEscape(repo.name)
+}
+
+func (p *giteaProvider) convertRepo(r *giteaRepo) *repository {
+	repo := &repository{
+		owner:         r.Owner.Login,
+		name:          r.Name,
+		webURL:        r.HTMLURL,
+		cloneURL:      r.CloneURL,
+		defaultBranch: r.DefaultBranch,
+		stars:         r.StarsCount,
+		fork:          r.Fork,
+		archived:      r.Archived,
+		pushedAt:      r.UpdatedAt,
+	}
+	return repo
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func newTestGiteaProvider(t *testing.T, api fakeAPI) *giteaProvider {
+	return newGiteaProvider(context.Background(), http.DefaultClient, api.start(t)+"/api/v1", "secret")
+}
+
+func TestGiteaListRepos(t *testing.T) {
+	languageRequests := 0
+	api := fakeAPI{}
+	api["/api/v1/orgs/acme/repos"] = func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			t.Errorf("missing Authorization header")
+		}
+		switch page := r.URL.Query().Get("page"); page {
+		case "1":
+			fmt.Fprint(w, `[{"name": "a", "owner": {"login": "acme"}, "stars_count": 3,
+				"html_url": "https://gitea.example/acme/a", "default_branch": "main"},
+				{"name": "b", "owner": {"login": "acme"}, "fork": true}]`)
+		case "2":
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Errorf("unexpected page %q", page)
+		}
+	}
+	api["/api/v1/repos/acme/a/languages"] = func(w http.ResponseWriter, r *http.Request) {
+		languageRequests++
+		fmt.Fprint(w, `{"Go": 1000, "Makefile": 20}`)
+	}
+
+	p := newTestGiteaProvider(t, api)
+	repos, err := p.listRepos("acme")
+	if err != nil {
+		t.Fatalf("listRepos: %v", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("listRepos: got %d repos, want 2", len(repos))
+	}
+	want := repository{
+		owner:         "acme",
+		name:          "a",
+		webURL:        "https://gitea.example/acme/a",
+		defaultBranch: "main",
+		stars:         3,
+	}
+	if !reflect.DeepEqual(*repos[0], want) {
+		t.Errorf("listRepos: got %+v, want %+v", *repos[0], want)
+	}
+	if !repos[1].fork {
+		t.Errorf("listRepos: %s should be a fork", repos[1].fullName())
+	}
+	if languageRequests != 0 {
+		t.Errorf("listRepos: made %d languages requests, want 0", languageRequests)
+	}
+
+	lang, err := p.repoLanguage(repos[0])
+	if err != nil || lang != "Go" {
+		t.Errorf("repoLanguage: got %q, %v, want Go", lang, err)
+	}
+	if _, err := p.repoLanguage(repos[1]); !isNotFound(err) {
+		t.Errorf("repoLanguage: got %v error, want 404", err)
+	}
+}
+
+func TestGiteaTreeAndBlob(t *testing.T) {
+	api := fakeAPI{}
+	api["/api/v1/repos/acme/a/git/trees/main"] = func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "1" {
+			fmt.Fprint(w, `{"truncated": true, "tree": [{"path": "docs", "type": "tree", "sha": "d1"},
+				{"path": "docs/README.md", "type": "blob", "sha": "b1"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"tree": [{"path": "LICENSE", "type": "blob", "sha": "b2"}]}`)
+	}
+	api["/api/v1/repos/acme/a/git/blobs/b2"] = func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"encoding": "base64", "content": "TUlUIExpY2Vuc2U="}`)
+	}
+
+	p := newTestGiteaProvider(t, api)
+	repo := &repository{owner: "acme", name: "a", ref: "main"}
+	entries, err := p.getTree(repo)
+	if err != nil {
+		t.Fatalf("getTree: %v", err)
+	}
+	wantEntries := []treeEntry{{path: "docs/README.md", sha: "b1"}, {path: "LICENSE", sha: "b2"}}
+	if !reflect.DeepEqual(entries, wantEntries) {
+		t.Errorf("getTree: got %+v, want %+v", entries, wantEntries)
+	}
+
+	contents, err := p.getBlob(repo, entries[1])
+	if err != nil || contents != "MIT License" {
+		t.Errorf("getBlob: got %q, %v", contents, err)
+	}
+}
+
+func TestGiteaHasTag(t *testing.T) {
+	api := fakeAPI{}
+	api["/api/v1/repos/acme/a/tags/v1.0"] = func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "v1.0"}`)
+	}
+
+	p := newTestGiteaProvider(t, api)
+	repo := &repository{owner: "acme", name: "a"}
+	for tag, want := range map[string]bool{"v1.0": true, "develop": false} {
+		got, err := p.hasTag(repo, tag)
+		if err != nil || got != want {
+			t.Errorf("hasTag(%s): got %v, %v, want %v", tag, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// githubProvider fetches repositories using GitHub API.
+type githubProvider struct {
+	ctx     context.Context
+	client  *github.Client
+	verbose bool
+}
+
+// newGithubProvider returns GitHub provider that uses httpClient to make requests.
+// If baseURL is not empty, it's used instead of the public GitHub API address.
+func newGithubProvider(ctx context.Context, httpClient *http.Client, baseURL string, verbose bool) (*githubProvider, error) {
+	client := github.NewClient(httpClient)
+	if baseURL != "" {
+		u, err := url.Parse(strings.TrimSuffix(baseURL, "/") + "/")
+		if err != nil {
+			return nil, fmt.Errorf("parse base URL: %v", err)
+		}
+		client.BaseURL = u
+	}
+	return &githubProvider{
+		ctx:     ctx,
+		client:  client,
+		verbose: verbose,
+	}, nil
+}
+
+func (p *githubProvider) getRepo(owner, name string) (*repository, error) {
+	var repo *github.Repository
+	err := p.retryRateLimit(func() (err error) {
+		repo, _, err = p.client.Repositories.Get(p.ctx, owner, name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.convertRepo(repo), nil
+}
+
+func (p *githubProvider) listRepos(owner string) ([]*repository, error) {
+	var result []*repository
+	opts := newRepositoryListOptions()
+	for {
+		var repos []*github.Repository
+		var resp *github.Response
+		err := p.retryRateLimit(func() (err error) {
+			repos, resp, err = p.client.Repositories.List(p.ctx, owner, opts)
+			return err
+		})
+		if err != nil {
+			if resp != nil && resp.NextPage == 0 && opts.Page > 1 {
+				// Ignore last page list error.
+				return result, nil
+			}
+			return nil, fmt.Errorf("list repos (page=%d): %v", opts.Page, err)
+		}
+
+		for _, repo := range repos {
+			result = append(result, p.convertRepo(repo))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return result, nil
+}
+
+func (p *githubProvider) getTree(repo *repository) ([]treeEntry, error) {
+	var tree *github.Tree
+	err := p.retryRateLimit(func() (err error) {
+		tree, _, err = p.client.Git.GetTree(p.ctx, repo.owner, repo.name, repo.ref, true)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if p.verbose && tree.GetTruncated() {
+		log.Printf("\t\tdebug: %s tree is truncated", repo.name)
+	}
+
+	entries := make([]treeEntry, 0, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		if entry.Path == nil || entry.GetType() != "blob" {
+			continue
+		}
+		entries = append(entries, treeEntry{
+			path: entry.GetPath(),
+			sha:  entry.GetSHA(),
+			mode: entry.GetMode(),
+		})
+	}
+	return entries, nil
+}
+
+func (p *githubProvider) getBlob(repo *repository, entry treeEntry) (string, error) {
+	opts := &github.RepositoryContentGetOptions{Ref: repo.ref}
+	var f *github.RepositoryContent
+	err := p.retryRateLimit(func() (err error) {
+		f, _, _, err = p.client.Repositories.GetContents(p.ctx, repo.owner, repo.name, entry.path, opts)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if f == nil {
+		return "", errors.New("contents is nil")
+	}
+	contents, err := f.GetContent()
+	if err != nil {
+		panic(fmt.Sprintf("get %s contents: %v", entry.path, err))
+	}
+	return contents, nil
+}
+
+// retryRateLimit calls fn again after the rate limit reset if it fails
+// because of the exhausted quota.
+//
+// When the last response reported no remaining requests, the client
+// returns *github.RateLimitError without making a request, so
+// rateLimitTransport never gets a chance to wait for the reset.
+func (p *githubProvider) retryRateLimit(fn func() error) error {
+	const maxAttempts = 3
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		rateErr, ok := err.(*github.RateLimitError)
+		if !ok || attempt == maxAttempts {
+			return err
+		}
+		// Add a small margin to avoid clock skew issues.
+		delay := time.Until(rateErr.Rate.Reset.Time) + time.Second
+		if delay < time.Second {
+			delay = time.Second
+		}
+		log.Printf("\trate limit exceeded, waiting %s until reset", delay.Round(time.Second))
+		if err := sleepContext(p.ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// isRateLimitError reports whether err is caused by the exhausted API quota.
+func isRateLimitError(err error) bool {
+	var rateErr *github.RateLimitError
+	return errors.As(err, &rateErr)
+}
+
+func (p *githubProvider) convertRepo(repo *github.Repository) *repository {
+	return &repository{
+		owner:         repo.GetOwner().GetLogin(),
+		name:          repo.GetName(),
+		webURL:        repo.GetHTMLURL(),
+		cloneURL:      repo.GetCloneURL(),
+		defaultBranch: repo.GetDefaultBranch(),
+		language:      repo.GetLanguage(),
+		stars:         repo.GetStargazersCount(),
+		fork:          repo.GetFork(),
+		archived:      repo.GetArchived(),
+		pushedAt:      repo.GetPushedAt().Time,
+	}
+}
+
+func newRepositoryListOptions() *github.RepositoryListOptions {
+	// Use some high value, github will limit it anyway,
+	// but we're interested in getting more data per one request.
+	return &github.RepositoryListOptions{
+		ListOptions: github.ListOptions{PerPage: math.MaxInt32},
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGithubRateLimitWait(t *testing.T) {
+	reset := time.Now().Add(time.Second)
+	requests := 0
+	api := fakeAPI{}
+	api["/repos/octo/a"] = func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// The first response uses the last request of the quota,
+		// the client refuses to make the next request until the reset.
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		fmt.Fprint(w, `{"name": "a", "owner": {"login": "octo"}, "default_branch": "main"}`)
+	}
+
+	p, err := newGithubProvider(context.Background(), http.DefaultClient, api.start(t), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		repo, err := p.getRepo("octo", "a")
+		if err != nil {
+			t.Fatalf("getRepo #%d: %v", i, err)
+		}
+		if repo.defaultBranch != "main" {
+			t.Errorf("getRepo #%d: got %q default branch, want main", i, repo.defaultBranch)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("made %d requests, want 2", requests)
+	}
+	if time.Now().Before(reset) {
+		t.Errorf("second request was made before the rate limit reset")
+	}
+}
+
+func TestRateLimitTransportRetry(t *testing.T) {
+	tests := []struct {
+		method   string
+		status   int
+		header   string
+		requests int
+	}{
+		{method: "GET", status: http.StatusBadGateway, requests: 2},
+		{method: "HEAD", status: http.StatusServiceUnavailable, requests: 2},
+		// The pull request could be already created.
+		{method: "POST", status: http.StatusBadGateway, requests: 1},
+		{method: "PATCH", status: http.StatusInternalServerError, requests: 1},
+		// Abuse detection rejects requests before processing them.
+		{method: "POST", status: http.StatusForbidden, header: "Retry-After", requests: 2},
+		{method: "GET", status: http.StatusNotFound, requests: 1},
+	}
+
+	for _, test := range tests {
+		requests := 0
+		api := fakeAPI{}
+		api["/repos/octo/a/pulls"] = func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests == 1 {
+				if test.header != "" {
+					w.Header().Set(test.header, "0")
+				}
+				w.WriteHeader(test.status)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+		client := &http.Client{Transport: &rateLimitTransport{
+			base:       http.DefaultTransport,
+			requests:   newRequestCounter(),
+			maxRetries: 1,
+		}}
+
+		req, err := http.NewRequest(test.method, api.start(t)+"/repos/octo/a/pulls", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s %d: %v", test.method, test.status, err)
+		}
+		resp.Body.Close()
+		if requests != test.requests {
+			t.Errorf("%s %d: made %d requests, want %d", test.method, test.status, requests, test.requests)
+		}
+	}
+}
+
+func TestRateLimitTransportDialError(t *testing.T) {
+	// Nothing listens on the closed server address,
+	// so even POST requests are retried.
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	transport := &rateLimitTransport{
+		base:       http.DefaultTransport,
+		requests:   newRequestCounter(),
+		maxRetries: 1,
+	}
+	client := &http.Client{Transport: transport}
+	resp, err := client.Post(srv.URL+"/repos/octo/a/pulls", "application/json", strings.NewReader("{}"))
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("POST to a closed server succeeded")
+	}
+	if n := transport.requests.requests(); n != 2 {
+		t.Errorf("made %d requests, want 2", n)
+	}
+}
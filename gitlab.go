@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// gitlabProvider fetches repositories using GitLab API v4.
+//
+// Repository owner is a user name or a group full path.
+type gitlabProvider struct {
+	api restClient
+}
+
+func newGitlabProvider(ctx context.Context, client *http.Client, baseURL, token string) *gitlabProvider {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Private-Token", token)
+	}
+	return &gitlabProvider{
+		api: restClient{
+			ctx:     ctx,
+			client:  client,
+			baseURL: baseURL,
+			header:  header,
+		},
+	}
+}
+
+type gitlabProject struct {
+	Path              string    `json:"path"`
+	PathWithNamespace string    `json:"path_with_namespace"`
+	WebURL            string    `json:"web_url"`
+	HTTPURLToRepo     string    `json:"http_url_to_repo"`
+	DefaultBranch     string    `json:"default_branch"`
+	StarCount         int       `json:"star_count"`
+	Archived          bool      `json:"archived"`
+	LastActivityAt    time.Time `json:"last_activity_at"`
+
+	Namespace struct {
+		FullPath string `json:"full_path"`
+	} `json:"namespace"`
+
+	ForkedFromProject *struct{} `json:"forked_from_project"`
+}
+
+type gitlabTreeEntry struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+func (p *gitlabProvider) getRepo(owner, name string) (*repository, error) {
+	var project gitlabProject
+	if _, err := p.api.getJSON("/projects/"+url.PathEscape(owner+"/"+name), &project); err != nil {
+		return nil, err
+	}
+	return p.convertRepo(&project), nil
+}
+
+func (p *gitlabProvider) listRepos(owner string) ([]*repository, error) {
+	projects, err := p.listProjects("/groups/" + url.PathEscape(owner) + "/projects?include_subgroups=true")
+	if isNotFound(err) {
+		projects, err = p.listProjects("/users/" + url.PathEscape(owner) + "/projects?")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]*repository, 0, len(projects))
+	for i := range projects {
+		repos = append(repos, p.convertRepo(&projects[i]))
+	}
+	return repos, nil
+}
+
+func (p *gitlabProvider) listProjects(path string) ([]gitlabProject, error) {
+	var projects []gitlabProject
+	page := "1"
+	for page != "" {
+		var batch []gitlabProject
+		header, err := p.api.getJSON(path+"&per_page=100&page="+page, &batch)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, batch...)
+		page = header.Get("X-Next-Page")
+	}
+	return projects, nil
+}
+
+func (p *gitlabProvider) getTree(repo *repository) ([]treeEntry, error) {
+	var entries []treeEntry
+	page := "1"
+	for page != "" {
+		var batch []gitlabTreeEntry
+		path := p.projectPath(repo) + "/repository/tree?recursive=true&per_page=100&page=" + page
+		if repo.ref != "" {
+			path += "&ref=" + url.QueryEscape(repo.ref)
+		}
+		header, err := p.api.getJSON(path, &batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range batch {
+			if entry.Type != "blob" {
+				continue
+			}
+			entries = append(entries, treeEntry{path: entry.Path, sha: entry.ID})
+		}
+		page = header.Get("X-Next-Page")
+	}
+	return entries, nil
+}
+
+func (p *gitlabProvider) getBlob(repo *repository, entry treeEntry) (string, error) {
+	data, _, err := p.api.get(p.projectPath(repo) + "/repository/blobs/" + entry.sha + "/raw")
+	return string(data), err
+}
+
+// repoLanguage fetches the repository major language with a separate request,
+// since the Projects API doesn't report it.
+func (p *gitlabProvider) repoLanguage(repo *repository) (string, error) {
+	var shares map[string]float64
+	if _, err := p.api.getJSON(p.projectPath(repo)+"/languages", &shares); err != nil {
+		return "", err
+	}
+	return majorLanguage(shares), nil
+}
+
+func (p *gitlabProvider) projectPath(repo *repository) string {
+	return "/projects/" + url.PathEscape(repo.fullName())
+}
+
+func (p *gitlabProvider) convertRepo(project *gitlabProject) *repository {
+	repo := &repository{
+		owner:         project.Namespace.FullPath,
+		name:          project.Path,
+		webURL:        project.WebURL,
+		cloneURL:      project.HTTPURLToRepo,
+		defaultBranch: project.DefaultBranch,
+		stars:         project.StarCount,
+		fork:          project.ForkedFromProject != nil,
+		archived:      project.Archived,
+		pushedAt:      project.LastActivityAt,
+	}
+	return repo
+}
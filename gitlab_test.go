@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func newTestGitlabProvider(t *testing.T, api fakeAPI) *gitlabProvider {
+	return newGitlabProvider(context.Background(), http.DefaultClient, api.start(t)+"/api/v4", "secret")
+}
+
+func TestGitlabListRepos(t *testing.T) {
+	api := fakeAPI{}
+	api["/api/v4/users/alice/projects"] = func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Private-Token") != "secret" {
+			t.Errorf("missing Private-Token header")
+		}
+		switch page := r.URL.Query().Get("page"); page {
+		case "1":
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"path": "a", "namespace": {"full_path": "alice"}, "star_count": 5,
+				"web_url": "https://gitlab.example/alice/a", "default_branch": "main"}]`)
+		case "2":
+			fmt.Fprint(w, `[{"path": "b", "namespace": {"full_path": "alice"},
+				"archived": true, "forked_from_project": {}}]`)
+		default:
+			t.Errorf("unexpected page %q", page)
+		}
+	}
+	api["/api/v4/projects/alice%2Fa/languages"] = func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Go": 80.5, "Shell": 19.5}`)
+	}
+
+	p := newTestGitlabProvider(t, api)
+	repos, err := p.listRepos("alice")
+	if err != nil {
+		t.Fatalf("listRepos: %v", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("listRepos: got %d repos, want 2", len(repos))
+	}
+	want := repository{
+		owner:         "alice",
+		name:          "a",
+		webURL:        "https://gitlab.example/alice/a",
+		defaultBranch: "main",
+		stars:         5,
+	}
+	if !reflect.DeepEqual(*repos[0], want) {
+		t.Errorf("listRepos: got %+v, want %+v", *repos[0], want)
+	}
+	if !repos[1].fork || !repos[1].archived {
+		t.Errorf("listRepos: %s should be an archived fork", repos[1].fullName())
+	}
+
+	lang, err := p.repoLanguage(repos[0])
+	if err != nil || lang != "Go" {
+		t.Errorf("repoLanguage: got %q, %v, want Go", lang, err)
+	}
+}
+
+func TestGitlabTreeAndBlob(t *testing.T) {
+	api := fakeAPI{}
+	api["/api/v4/projects/alice%2Fa/repository/tree"] = func(w http.ResponseWriter, r *http.Request) {
+		if ref := r.URL.Query().Get("ref"); ref != "v1.0" {
+			t.Errorf("tree: got %q ref, want v1.0", ref)
+		}
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"id": "d1", "type": "tree", "path": "docs"},
+				{"id": "b1", "type": "blob", "path": "docs/README.md"}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"id": "b2", "type": "blob", "path": "LICENSE"}]`)
+	}
+	api["/api/v4/projects/alice%2Fa/repository/blobs/b2/raw"] = func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "MIT License")
+	}
+
+	p := newTestGitlabProvider(t, api)
+	repo := &repository{owner: "alice", name: "a", ref: "v1.0"}
+	entries, err := p.getTree(repo)
+	if err != nil {
+		t.Fatalf("getTree: %v", err)
+	}
+	wantEntries := []treeEntry{{path: "docs/README.md", sha: "b1"}, {path: "LICENSE", sha: "b2"}}
+	if !reflect.DeepEqual(entries, wantEntries) {
+		t.Errorf("getTree: got %+v, want %+v", entries, wantEntries)
+	}
+
+	contents, err := p.getBlob(repo, entries[1])
+	if err != nil || contents != "MIT License" {
+		t.Errorf("getBlob: got %q, %v", contents, err)
+	}
+}
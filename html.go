@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+)
+
+// htmlReporter writes a self-contained HTML page with warnings grouped
+// by repository. Checkers stats table doubles as a warnings filter.
+type htmlReporter struct {
+	filename string
+	l        *linter
+	page     htmlReport
+}
+
+type htmlReport struct {
+	Title    string
+	Checkers []*htmlChecker
+	Repos    []*htmlRepo
+
+	NumRepos    int
+	NumWarnings int
+}
+
+type htmlChecker struct {
+	Name        string
+	Description string
+	Count       int
+}
+
+type htmlRepo struct {
+	Name     string
+	URL      string
+	Warnings []*htmlWarning
+}
+
+type htmlWarning struct {
+	Checker  string
+	Severity string
+	Location string
+	URL      string
+	Message  string
+}
+
+func (r *htmlReporter) report(repo *repository, warnings []warning) error {
+	r.page.NumRepos++
+	if len(warnings) == 0 {
+		return nil
+	}
+	r.page.NumWarnings += len(warnings)
+
+	htmlRepo := &htmlRepo{
+		Name: r.l.repoPath(repo),
+		URL:  repo.webURL,
+	}
+	for _, w := range warnings {
+		hw := &htmlWarning{
+			Checker:  w.checker,
+			Severity: w.severity.String(),
+			Message:  w.message,
+		}
+		if w.file != "" {
+			hw.Location = w.position()
+			hw.URL = fileURL(r.l.providerKind, repo, w.file, w.line)
+		}
+		htmlRepo.Warnings = append(htmlRepo.Warnings, hw)
+	}
+	r.page.Repos = append(r.page.Repos, htmlRepo)
+	return nil
+}
+
+func (r *htmlReporter) finish(s *runSummary) error {
+	r.page.Title = "repolint report"
+	if r.l.user != "" {
+		r.page.Title += ": " + r.l.user
+	}
+
+	counts := make(map[string]int)
+	for _, repo := range r.page.Repos {
+		for _, w := range repo.Warnings {
+			counts[w.Checker]++
+		}
+	}
+	for _, name := range r.l.reportedCheckers(counts) {
+		r.page.Checkers = append(r.page.Checkers, &htmlChecker{
+			Name:        name,
+			Description: checkerDocs[name],
+			Count:       counts[name],
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := htmlReportTemplate.Execute(&buf, &r.page); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.filename, buf.Bytes(), 0644)
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+td, th { border: 1px solid #ddd; padding: 4px 8px; text-align: left; vertical-align: top; }
+.error { color: #b00; }
+.warning { color: #b60; }
+.info { color: #06b; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Number of checked repositories: {{.NumRepos}}. Warnings reported: {{.NumWarnings}}.</p>
+
+<table>
+<tr><th></th><th>Kind of an issue</th><th>Numbers reported</th></tr>
+{{- range .Checkers}}
+<tr title="{{.Description}}">
+<td><input type="checkbox" class="filter" value="{{.Name}}" checked></td>
+<td>{{.Name}}</td>
+<td>{{.Count}}</td>
+</tr>
+{{- end}}
+</table>
+
+{{- range .Repos}}
+<div class="repo">
+<h2>{{if .URL}}<a href="{{.URL}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</h2>
+<table>
+{{- range .Warnings}}
+<tr class="warn" data-checker="{{.Checker}}">
+<td>{{.Checker}}</td>
+<td class="{{.Severity}}">{{.Severity}}</td>
+<td>{{if .URL}}<a href="{{.URL}}">{{.Location}}</a>{{else}}{{.Location}}{{end}}</td>
+<td>{{.Message}}</td>
+</tr>
+{{- end}}
+</table>
+</div>
+{{- end}}
+
+<script>
+function applyFilters() {
+  var enabled = {};
+  document.querySelectorAll(".filter").forEach(function(el) {
+    enabled[el.value] = el.checked;
+  });
+  document.querySelectorAll(".repo").forEach(function(repo) {
+    var visible = 0;
+    repo.querySelectorAll(".warn").forEach(function(row) {
+      var show = enabled[row.dataset.checker];
+      row.classList.toggle("hidden", !show);
+      if (show) visible++;
+    });
+    repo.classList.toggle("hidden", visible == 0);
+  });
+}
+document.querySelectorAll(".filter").forEach(function(el) {
+  el.addEventListener("change", applyFilters);
+});
+</script>
+</body>
+</html>
+`))
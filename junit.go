@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name          `xml:"testsuites"`
+	Suites  []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Cases    []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitReporter prints JUnit XML report with a test suite per repository.
+// Every enabled or reported checker is a test case that fails if it has any warnings.
+type junitReporter struct {
+	w      io.Writer
+	l      *linter
+	suites junitTestSuites
+}
+
+func (r *junitReporter) report(repo *repository, warnings []warning) error {
+	byChecker := make(map[string][]warning)
+	counts := make(map[string]int)
+	for _, w := range warnings {
+		byChecker[w.checker] = append(byChecker[w.checker], w)
+		counts[w.checker]++
+	}
+
+	suite := &junitTestSuite{Name: r.l.repoPath(repo)}
+	for _, name := range r.l.reportedCheckers(counts) {
+		tc := &junitTestCase{Name: name, ClassName: suite.Name}
+		if list := byChecker[name]; len(list) != 0 {
+			lines := make([]string, len(list))
+			worst := severityInfo
+			for i, w := range list {
+				lines[i] = w.String()
+				if w.severity > worst {
+					worst = w.severity
+				}
+			}
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d warnings", len(list)),
+				Type:    worst.String(),
+				Text:    strings.Join(lines, "\n"),
+			}
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+	r.suites.Suites = append(r.suites.Suites, suite)
+	return nil
+}
+
+func (r *junitReporter) finish(s *runSummary) error {
+	return writeXML(r.w, &r.suites)
+}
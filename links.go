@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// docLink is a link found in a documentation file.
+type docLink struct {
+	url string
+
+	// offset is a link byte offset inside the file contents.
+	offset int
+}
+
+var (
+	// linkURLRE matches absolute http and https URLs.
+	// It stops at characters that delimit URLs in Markdown,
+	// reStructuredText and HTML, like "<url>" and href="url".
+	linkURLRE = regexp.MustCompile("https?://[^\\s<>\"'`\\[\\]{}|\\\\^]+")
+
+	// inlineCodeRE matches Markdown code spans.
+	inlineCodeRE = regexp.MustCompile("``[^`]*``|`[^`\n]*`")
+
+	// rstLiteralRE matches reStructuredText inline literals.
+	// Single backquotes are used for links there, like `text <url>`_.
+	rstLiteralRE = regexp.MustCompile("``[^`]*``")
+)
+
+// extractLinks returns absolute URLs found in the file contents.
+// Links inside code blocks and code spans are ignored, they are
+// usually examples, like "http://localhost:8080/api".
+func extractLinks(filename, contents string) []docLink {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".html", ".htm":
+		// Inline code has no special meaning in HTML.
+	case ".rst":
+		contents = blankRSTLiterals(contents)
+	default:
+		contents = blankMarkdownCode(contents)
+	}
+
+	var links []docLink
+	for _, loc := range linkURLRE.FindAllStringIndex(contents, -1) {
+		u := trimLinkURL(contents[loc[0]:loc[1]])
+		if strings.HasSuffix(u, "://") {
+			continue
+		}
+		links = append(links, docLink{url: u, offset: loc[0]})
+	}
+	return links
+}
+
+// trimLinkURL removes trailing characters that are most likely
+// a surrounding text punctuation, not a part of the URL.
+func trimLinkURL(u string) string {
+	for {
+		trimmed := strings.TrimRight(u, ".,;:!?*_~")
+		// "[text](url)" and "(see url)" closing parenthesis,
+		// but not "wiki/Go_(programming_language)".
+		if strings.HasSuffix(trimmed, ")") && strings.Count(trimmed, ")") > strings.Count(trimmed, "(") {
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+		if trimmed == u {
+			return u
+		}
+		u = trimmed
+	}
+}
+
+// blankMarkdownCode replaces Markdown fenced code blocks and code spans
+// with spaces, so offsets of the remaining text stay the same.
+func blankMarkdownCode(contents string) string {
+	return inlineCodeRE.ReplaceAllStringFunc(blankMarkdownFences(contents), blankText)
+}
+
+// blankMarkdownFences replaces Markdown fenced code blocks with spaces.
+func blankMarkdownFences(contents string) string {
+	lines := strings.SplitAfter(contents, "\n")
+	fence := ""
+	for i, l := range lines {
+		switch {
+		case fence != "":
+			if strings.HasPrefix(strings.TrimSpace(l), fence) {
+				fence = ""
+			}
+			lines[i] = blankText(l)
+		case codeFence(lines, i) != "":
+			fence = codeFence(lines, i)
+			lines[i] = blankText(l)
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// blankRSTLiterals replaces reStructuredText literal blocks
+// (indented blocks after a "::" line) and inline literals with spaces.
+func blankRSTLiterals(contents string) string {
+	lines := strings.SplitAfter(contents, "\n")
+	literal := false
+	for i, l := range lines {
+		trimmed := strings.TrimSpace(l)
+		switch {
+		case literal && trimmed == "":
+		case literal && (l[0] == ' ' || l[0] == '\t'):
+			lines[i] = blankText(l)
+		default:
+			literal = strings.HasSuffix(trimmed, "::")
+		}
+	}
+	contents = strings.Join(lines, "")
+	return rstLiteralRE.ReplaceAllStringFunc(contents, blankText)
+}
+
+// blankText replaces all s characters, except newlines, with spaces.
+func blankText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' {
+			return r
+		}
+		return ' '
+	}, s)
+}
+
+// linkStatus is a link check result category.
+type linkStatus int
+
+const (
+	linkOK linkStatus = iota
+	linkNotFound
+	linkHTTPError
+	linkDNSFailure
+	linkTLSError
+	linkTimeout
+	linkConnError
+)
+
+func (s linkStatus) String() string {
+	switch s {
+	case linkOK:
+		return "ok"
+	case linkNotFound:
+		return "not found"
+	case linkHTTPError:
+		return "HTTP error"
+	case linkDNSFailure:
+		return "DNS failure"
+	case linkTLSError:
+		return "TLS error"
+	case linkTimeout:
+		return "timeout"
+	case linkConnError:
+		return "connection error"
+	default:
+		return fmt.Sprintf("linkStatus(%d)", int(s))
+	}
+}
+
+// linkResult is a single URL check result.
+type linkResult struct {
+	status linkStatus
+
+	// detail is a response status or an error text.
+	detail string
+}
+
+func (r linkResult) String() string {
+	return r.status.String() + ": " + r.detail
+}
+
+// linkChecker checks whether URLs can be followed.
+// Results are cached, so every URL is requested only once.
+// It's safe for concurrent use.
+type linkChecker struct {
+	client  *http.Client
+	timeout time.Duration
+
+	// hostLimit is a max number of concurrent requests per host.
+	hostLimit int
+
+	mu      sync.Mutex
+	hosts   map[string]chan struct{}
+	results map[string]*linkCheck
+}
+
+// linkCheck is an in-progress or finished URL check.
+type linkCheck struct {
+	done   chan struct{}
+	result linkResult
+}
+
+func newLinkChecker(client *http.Client, timeout time.Duration, hostLimit int) *linkChecker {
+	return &linkChecker{
+		client:    client,
+		timeout:   timeout,
+		hostLimit: hostLimit,
+		hosts:     make(map[string]chan struct{}),
+		results:   make(map[string]*linkCheck),
+	}
+}
+
+var linkCheckers = struct {
+	sync.Mutex
+	m map[string]*linkChecker
+}{m: make(map[string]*linkChecker)}
+
+// sharedLinkChecker returns a link checker with the specified options.
+// Checkers are shared, so the results cache and the per-host limits
+// work across all repositories.
+func sharedLinkChecker(timeout time.Duration, hostLimit int) *linkChecker {
+	key := fmt.Sprintf("%v/%d", timeout, hostLimit)
+	linkCheckers.Lock()
+	defer linkCheckers.Unlock()
+	lc := linkCheckers.m[key]
+	if lc == nil {
+		lc = newLinkChecker(&http.Client{}, timeout, hostLimit)
+		linkCheckers.m[key] = lc
+	}
+	return lc
+}
+
+// check returns the rawURL check result.
+func (lc *linkChecker) check(ctx context.Context, rawURL string) linkResult {
+	lc.mu.Lock()
+	c := lc.results[rawURL]
+	if c != nil {
+		lc.mu.Unlock()
+		<-c.done
+		return c.result
+	}
+	c = &linkCheck{done: make(chan struct{})}
+	lc.results[rawURL] = c
+	lc.mu.Unlock()
+
+	c.result = lc.request(ctx, rawURL)
+	close(c.done)
+	return c.result
+}
+
+// request makes a HEAD request, falling back to GET,
+// because some servers don't implement HEAD properly.
+func (lc *linkChecker) request(ctx context.Context, rawURL string) linkResult {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return linkResult{status: linkConnError, detail: err.Error()}
+	}
+
+	slot := lc.hostSlot(u.Host)
+	slot <- struct{}{}
+	defer func() { <-slot }()
+
+	result := lc.do(ctx, "HEAD", rawURL)
+	switch result.status {
+	case linkNotFound, linkHTTPError:
+		return lc.do(ctx, "GET", rawURL)
+	default:
+		// Network errors are not HEAD-specific.
+		return result
+	}
+}
+
+func (lc *linkChecker) hostSlot(host string) chan struct{} {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	slot := lc.hosts[host]
+	if slot == nil {
+		slot = make(chan struct{}, lc.hostLimit)
+		lc.hosts[host] = slot
+	}
+	return slot
+}
+
+func (lc *linkChecker) do(ctx context.Context, method, rawURL string) linkResult {
+	ctx, cancel := context.WithTimeout(ctx, lc.timeout)
+	defer cancel()
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		return linkResult{status: linkConnError, detail: err.Error()}
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", "repolint (+https://github.com/quasilyte/repolint)")
+	resp, err := lc.client.Do(req)
+	if err != nil {
+		return classifyLinkError(err, lc.timeout)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	switch code := resp.StatusCode; {
+	case code < 400:
+		return linkResult{status: linkOK}
+	case code == http.StatusUnauthorized, code == http.StatusForbidden, code == http.StatusTooManyRequests:
+		// The page can exist, but it's not possible to tell.
+		return linkResult{status: linkOK}
+	case code == http.StatusNotFound, code == http.StatusGone:
+		return linkResult{status: linkNotFound, detail: resp.Status}
+	default:
+		return linkResult{status: linkHTTPError, detail: resp.Status}
+	}
+}
+
+// classifyLinkError returns a request error category.
+func classifyLinkError(err error, timeout time.Duration) linkResult {
+	var (
+		dnsErr       *net.DNSError
+		netErr       net.Error
+		unknownCA    x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		recordErr    tls.RecordHeaderError
+		verifyErr    *tls.CertificateVerificationError
+		urlErr       *url.Error
+		isCertErr    = errors.As(err, &unknownCA) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+		isTLSErr     = isCertErr || errors.As(err, &recordErr) || errors.As(err, &verifyErr)
+		isTimeoutErr = errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+	)
+	// Strip the "Get "url": " prefix, the URL is already in the warning.
+	detail := err
+	if errors.As(err, &urlErr) {
+		detail = urlErr.Err
+	}
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return linkResult{status: linkDNSFailure, detail: dnsErr.Err}
+	case isTLSErr:
+		return linkResult{status: linkTLSError, detail: detail.Error()}
+	case isTimeoutErr:
+		return linkResult{status: linkTimeout, detail: fmt.Sprintf("no response in %v", timeout)}
+	default:
+		return linkResult{status: linkConnError, detail: detail.Error()}
+	}
+}
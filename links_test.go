@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLinkCheckerStatus(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/ok", "/":
+		case "/nohead":
+			if r.Method == "HEAD" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/moved-missing":
+			http.Redirect(w, r, "/missing", http.StatusFound)
+		case "/private":
+			w.WriteHeader(http.StatusForbidden)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		path string
+		want linkResult
+	}{
+		{"/ok", linkResult{status: linkOK}},
+		{"/nohead", linkResult{status: linkOK}},
+		{"/moved", linkResult{status: linkOK}},
+		{"/moved-missing", linkResult{status: linkNotFound, detail: "404 Not Found"}},
+		{"/private", linkResult{status: linkOK}},
+		{"/missing", linkResult{status: linkNotFound, detail: "404 Not Found"}},
+		{"/gone", linkResult{status: linkNotFound, detail: "410 Gone"}},
+		{"/broken", linkResult{status: linkHTTPError, detail: "500 Internal Server Error"}},
+	}
+	lc := newLinkChecker(srv.Client(), time.Second, 4)
+	for _, test := range tests {
+		if got := lc.check(context.Background(), srv.URL+test.path); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.path, got, test.want)
+		}
+	}
+
+	// HEAD falls back to GET, the result is cached.
+	calls = calls[:0]
+	lc = newLinkChecker(srv.Client(), time.Second, 4)
+	lc.check(context.Background(), srv.URL+"/nohead")
+	lc.check(context.Background(), srv.URL+"/nohead")
+	want := []string{"HEAD /nohead", "GET /nohead"}
+	if fmt.Sprint(calls) != fmt.Sprint(want) {
+		t.Errorf("nohead requests: got %q, want %q", calls, want)
+	}
+}
+
+func TestLinkCheckerHostLimit(t *testing.T) {
+	const hostLimit = 2
+	var active, maxActive int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	lc := newLinkChecker(srv.Client(), time.Second, hostLimit)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lc.check(context.Background(), fmt.Sprintf("%s/page%d", srv.URL, i))
+		}(i)
+	}
+	wg.Wait()
+	if maxActive > hostLimit {
+		t.Errorf("got %d concurrent requests, want at most %d", maxActive, hostLimit)
+	}
+}
+
+func TestLinkCheckerNetworkErrors(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsSrv.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedURL := "http://" + ln.Addr().String()
+	ln.Close()
+
+	tests := []struct {
+		url     string
+		timeout time.Duration
+		want    linkStatus
+	}{
+		{slow.URL, 50 * time.Millisecond, linkTimeout},
+		// The test server certificate is not trusted by the default client.
+		{tlsSrv.URL, 5 * time.Second, linkTLSError},
+		{closedURL, 5 * time.Second, linkConnError},
+	}
+	for _, test := range tests {
+		lc := newLinkChecker(&http.Client{}, test.timeout, 4)
+		if got := lc.check(context.Background(), test.url); got.status != test.want {
+			t.Errorf("%s: got %q, want %s status", test.url, got, test.want)
+		}
+	}
+}
+
+func TestClassifyLinkError(t *testing.T) {
+	urlErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://example.com", Err: err}
+	}
+	tests := []struct {
+		err  error
+		want linkResult
+	}{
+		{
+			urlErr(&net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "example.com"}}),
+			linkResult{status: linkDNSFailure, detail: "no such host"},
+		},
+		{
+			urlErr(context.DeadlineExceeded),
+			linkResult{status: linkTimeout, detail: "no response in 5s"},
+		},
+		{
+			urlErr(x509.UnknownAuthorityError{}),
+			linkResult{status: linkTLSError, detail: x509.UnknownAuthorityError{}.Error()},
+		},
+		{
+			urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: fmt.Errorf("connection refused")}),
+			linkResult{status: linkConnError, detail: "dial tcp: connection refused"},
+		},
+	}
+	for _, test := range tests {
+		if got := classifyLinkError(test.err, 5*time.Second); got != test.want {
+			t.Errorf("classifyLinkError(%v): got %q, want %q", test.err, got, test.want)
+		}
+	}
+}
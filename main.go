@@ -7,21 +7,42 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
-	"math"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
-
-	"github.com/google/go-github/github"
-	"golang.org/x/oauth2"
 )
 
+// commands are subcommands that don't lint repositories.
+var commands = map[string]func(args []string) error{
+	"diff":  runDiff,
+	"stats": runStats,
+}
+
 func main() {
 	log.SetFlags(0)
 
-	l := linter{}
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				log.Fatalf("%s: %v", os.Args[1], err)
+			}
+			return
+		}
+	}
+
+	l := linter{args: os.Args[1:]}
+	if len(l.args) != 0 && l.args[0] == "pr" {
+		// "pr" command opens pull requests with the fixes
+		// instead of writing patches.
+		l.openPR = true
+		l.args = l.args[1:]
+	}
 
 	defer l.cleanup()
 	steps := []struct {
@@ -31,30 +52,50 @@ func main() {
 		{"init temp dir", l.initTempDir},
 		{"parse flags", l.parseFlags},
 		{"init checkers", l.initCheckers},
+		{"init reporter", l.initReporter},
+		{"load baseline", l.loadBaseline},
 		{"read token", l.readToken},
-		{"init client", l.initClient},
+		{"init provider", l.initProvider},
+		{"init source", l.initSource},
 		{"get repos list", l.getReposList},
 		{"disable checkers", l.disableCheckers},
+		{"load checkpoint", l.loadCheckpoint},
 		{"lint repos", l.lintRepos},
 	}
 	for _, step := range steps {
 		if err := step.fn(); err != nil {
+			// log.Fatalf doesn't run deferred calls.
+			l.cleanup()
 			log.Fatalf("%s: %v", step.name, err)
 		}
 	}
+	if l.failed {
+		l.cleanup()
+		os.Exit(2)
+	}
 }
 
 type linter struct {
+	// args are command-line arguments without the command name.
+	args []string
+
 	singleRepo string
+	dir        string
+	gitDir     string
 	user       string
 	lang       string
 	token      string
 	tokenPath  string
 	disable    string
-	repos      []*github.Repository
+	ref        string
+	repos      []*repository
 
-	ctx    context.Context
-	client *github.Client
+	providerKind string
+	baseURL      string
+
+	ctx      context.Context
+	provider repoProvider
+	source   repoSource
 
 	verbose      bool
 	minStars     int
@@ -62,9 +103,74 @@ type linter struct {
 	skipArchived bool
 	skipInactive bool
 	skipVendor   bool
-	offset       int
+	clone        bool
+	jobs         int
+
+	// cacheDir is a persistent cache directory path.
+	// If empty, nothing is cached between runs.
+	cacheDir string
+	cache    *diskCache
+
+	// statePath is a checkpoint file path.
+	// If empty, no checkpoints are saved.
+	statePath string
+	resume    bool
+	state     *checkpoint
+
+	format   string
+	reporter reporter
+
+	// fix enables fixable warnings patches generation.
+	// Patches are written to patchDir, unless it's a -dir
+	// mode where fixes are applied to the working tree.
+	fix      bool
+	patchDir string
+
+	// openPR is set for the "pr" command.
+	openPR   bool
+	prBranch string
+	dryRun   bool
+
+	// baselinePath is a file with already known warnings.
+	// If empty, all warnings are reported.
+	baselinePath string
+	baseline     *baseline
+
+	// writeBaselinePath is a file where all warnings are saved.
+	// If empty, no baseline is written.
+	writeBaselinePath string
+	baselineWriter    *baselineWriter
+
+	// failOn is a minimal severity of the reported warnings
+	// that makes the run fail. If empty, the run never fails.
+	failOn string
+	failed bool
+
+	// htmlPath is an HTML report file path.
+	// If empty, no HTML report is written.
+	htmlPath string
 
-	requests int
+	requests *requestCounter
+
+	// fetchLimit bounds the number of concurrent file fetches.
+	fetchLimit chan struct{}
+
+	// configPath is a config file path.
+	// If empty, .repolint.yml is looked up in the working directory
+	// and, in -dir mode, in the repository root.
+	configPath string
+	config     *config
+
+	// configFile is an absolute path of the loaded config file.
+	// It's empty if no config file was found.
+	configFile string
+
+	// explicitFlags are flags that were passed on the command line.
+	explicitFlags map[string]bool
+
+	// flagDisabled are checkers that were disabled by an explicit
+	// -disable flag. Configs can't enable them.
+	flagDisabled map[string]bool
 
 	checkers map[string]fileChecker
 
@@ -72,6 +178,13 @@ type linter struct {
 }
 
 func (l *linter) cleanup() {
+	if l.baselineWriter != nil {
+		// The baseline is incomplete if the run was aborted,
+		// but the warnings written so far are still kept.
+		if err := l.baselineWriter.close(); err != nil {
+			log.Printf("cleanup before exit: close baseline: %v", err)
+		}
+	}
 	err := os.RemoveAll(l.tempDir)
 	if err != nil {
 		log.Printf("cleanup before exit: %v", err)
@@ -88,9 +201,21 @@ func (l *linter) parseFlags() error {
 	flag.StringVar(&l.lang, "lang", "",
 		`if non-empty, acts as a repository major language filter; example: "Go"`)
 	flag.StringVar(&l.user, "user", "",
-		`GitHub user/organization name`)
+		`user/organization name; GitLab group path or Bitbucket project key for these providers`)
 	flag.StringVar(&l.singleRepo, "repo", "",
-		`GitHub repository name for a single-repo mode`)
+		`repository name for a single-repo mode`)
+	flag.StringVar(&l.providerKind, "provider", "github",
+		`repository hosting provider: github, gitlab, gitea or bitbucket`)
+	flag.StringVar(&l.baseURL, "baseURL", "",
+		`provider API base URL for self-hosted installations; example: "https://gitlab.example.com/api/v4"`)
+	flag.StringVar(&l.ref, "ref", "",
+		`branch, tag or commit hash to check; by default, repository default branch is checked`)
+	flag.StringVar(&l.dir, "dir", "",
+		`local repository working tree path for a local mode that makes no API requests`)
+	flag.StringVar(&l.gitDir, "gitDir", "",
+		`local bare repository or git bundle path for a local mode that makes no API requests`)
+	flag.BoolVar(&l.clone, "clone", false,
+		`whether to fetch repository files with a shallow git clone instead of per-file API requests`)
 	flag.BoolVar(&l.verbose, "v", false,
 		`verbose mode that turns on additional debug output`)
 	flag.IntVar(&l.minStars, "minStars", 1,
@@ -103,23 +228,135 @@ func (l *linter) parseFlags() error {
 		`whether to skip repositories with latest push dated more than 6 months ago`)
 	flag.BoolVar(&l.skipVendor, "skipVendor", true,
 		`whether to skip vendor folders and their contents`)
-	flag.IntVar(&l.offset, "offset", 0,
-		`how many repositories to skip`)
+	flag.StringVar(&l.statePath, "state", "",
+		`checkpoint file path; if not empty, the progress is saved there after every checked repository`)
+	flag.BoolVar(&l.resume, "resume", false,
+		`whether to continue an interrupted run from the -state checkpoint file`)
+	flag.StringVar(&l.cacheDir, "cacheDir", "",
+		`if not empty, fetched files and API responses are cached there between runs`)
+	flag.IntVar(&l.jobs, "j", 1,
+		`how many repositories are checked and how many files are fetched concurrently`)
+	flag.StringVar(&l.format, "format", "text",
+		`output format: text, json, jsonl, sarif, checkstyle, junit or tasks`)
+	flag.BoolVar(&l.fix, "fix", false,
+		`whether to write patches that fix the warnings; in -dir mode, fixes are applied to the files`)
+	flag.StringVar(&l.patchDir, "patchDir", ".",
+		`directory where -fix patches are written, one per repository`)
+	flag.StringVar(&l.prBranch, "branch", "repolint-fixes",
+		`pr command: fork branch name for the fixes`)
+	flag.BoolVar(&l.dryRun, "dryRun", false,
+		`pr command: print the API calls that modify something instead of making them`)
+	flag.StringVar(&l.baselinePath, "baseline", "",
+		`previous results file (text, json or jsonl); only warnings that are not there are reported`)
+	flag.StringVar(&l.writeBaselinePath, "writeBaseline", "",
+		`if not empty, all warnings are saved to that file to be used as a -baseline later`)
+	flag.StringVar(&l.failOn, "failOn", "",
+		`if not empty, exit with code 2 when a warning of that or higher severity is reported: info, warning or error`)
+	flag.StringVar(&l.htmlPath, "html", "",
+		`if not empty, a self-contained HTML report is written to that file`)
+	flag.StringVar(&l.configPath, "config", "",
+		`config file path; by default, .repolint.yml in the working directory is used, if any`)
 	flag.StringVar(&l.tokenPath, "tokenPath", "",
 		`the path to the token file`)
 	flag.StringVar(&l.disable, "disable", "missing file, acronym, broken link",
 		`comma-separated list of check names to be disabled`)
 
-	flag.Parse()
+	if err := flag.CommandLine.Parse(l.args); err != nil {
+		return err
+	}
+	if err := l.loadConfig(); err != nil {
+		return err
+	}
 
-	if l.user == "" {
+	if l.dir != "" && l.gitDir != "" {
+		return errors.New("-dir and -gitDir can't be used together")
+	}
+	if l.dir != "" && l.ref != "" {
+		return errors.New("-ref can't be used with -dir")
+	}
+	if l.user == "" && l.localRepo() == "" {
 		return errors.New("-user argument can't be empty")
 	}
+	if l.resume && l.statePath == "" {
+		return errors.New("-resume requires -state argument")
+	}
+	if l.openPR {
+		if l.providerKind != "github" {
+			return errors.New("pr command only supports github provider")
+		}
+		if l.singleRepo == "" {
+			return errors.New("pr command requires -repo argument")
+		}
+		if l.clone || l.localRepo() != "" {
+			return errors.New("pr command can't be used with -clone, -dir or -gitDir")
+		}
+		l.fix = true
+	}
+	if l.jobs < 1 {
+		return errors.New("-j argument should be positive")
+	}
+	if l.failOn != "" {
+		if _, err := parseSeverity(l.failOn); err != nil {
+			return fmt.Errorf("-failOn: %v", err)
+		}
+	}
+	l.fetchLimit = make(chan struct{}, l.jobs)
 
 	return nil
 }
 
+// loadConfig reads a config file and applies its flags.
+// Flags that were passed explicitly keep their values.
+func (l *linter) loadConfig() error {
+	l.config = &config{}
+	l.explicitFlags = make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		l.explicitFlags[f.Name] = true
+	})
+
+	filename := l.configPath
+	if filename == "" {
+		candidates := []string{configFilename}
+		if l.dir != "" {
+			candidates = append(candidates, filepath.Join(l.dir, configFilename))
+		}
+		for _, candidate := range candidates {
+			if _, err := os.Stat(candidate); err == nil {
+				filename = candidate
+				break
+			}
+		}
+	}
+	if filename == "" {
+		return nil
+	}
+
+	cfg, err := loadConfig(filename)
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(cfg.Flags) {
+		if name == "config" {
+			return fmt.Errorf("%s: config flag can't be set by a config file", filename)
+		}
+		if l.explicitFlags[name] {
+			continue
+		}
+		if err := flag.Set(name, cfg.Flags[name]); err != nil {
+			return fmt.Errorf("%s: %s flag: %v", filename, name, err)
+		}
+	}
+	l.config = cfg
+	l.configFile, err = filepath.Abs(filename)
+	return err
+}
+
 func (l *linter) readToken() error {
+	if l.localRepo() != "" {
+		// Local mode doesn't need any authorization.
+		return nil
+	}
+
 	token := os.Getenv("TOKEN")
 	if token != "" {
 		l.token = token
@@ -140,130 +377,497 @@ func (l *linter) readToken() error {
 }
 
 func (l *linter) initCheckers() error {
-	l.checkers = map[string]fileChecker{
+	l.checkers = newCheckers(l.config)
+	return nil
+}
+
+func (l *linter) initReporter() error {
+	r, err := newReporter(l.format, os.Stdout, l)
+	if err != nil {
+		return err
+	}
+	l.reporter = r
+	if l.htmlPath != "" {
+		l.reporter = multiReporter{r, &htmlReporter{filename: l.htmlPath, l: l}}
+	}
+	return nil
+}
+
+func (l *linter) loadBaseline() error {
+	if l.writeBaselinePath != "" {
+		w, err := newBaselineWriter(l.writeBaselinePath, l.resume)
+		if err != nil {
+			return err
+		}
+		l.baselineWriter = w
+	}
+
+	if l.baselinePath == "" {
+		return nil
+	}
+	b, err := loadBaseline(l.baselinePath, l.localRepo() != "")
+	if err != nil {
+		return err
+	}
+	l.baseline = b
+	return nil
+}
+
+// newCheckers returns a fresh set of all checkers configured by cfg.
+func newCheckers(cfg *config) map[string]fileChecker {
+	opts := &cfg.Checkers
+	brokenLinkExclude := defaultBrokenLinkExclude
+	if opts.BrokenLink.Exclude != nil {
+		brokenLinkExclude = *opts.BrokenLink.Exclude
+	}
+	brokenLinkTimeout := defaultBrokenLinkTimeout
+	if opts.BrokenLink.timeout != 0 {
+		brokenLinkTimeout = opts.BrokenLink.timeout
+	}
+	brokenLinkHostLimit := defaultBrokenLinkHostLimit
+	if opts.BrokenLink.HostLimit != 0 {
+		brokenLinkHostLimit = opts.BrokenLink.HostLimit
+	}
+	return map[string]fileChecker{
 		"missing file":     &missingFileChecker{},
-		"broken link":      &brokenLinkChecker{},
-		"misspell":         &misspellChecker{},
-		"var name typo":    newVarTypoChecker(),
-		"unwanted file":    newUnwantedFileChecker(),
+		"broken link":      newBrokenLinkChecker(brokenLinkExclude, brokenLinkTimeout, brokenLinkHostLimit),
+		"relative link":    &relativeLinkChecker{},
+		"misspell":         newMisspellChecker(opts.Misspell.Locale, opts.Misspell.Ignore),
+		"var name typo":    newVarTypoChecker(mergeOptions(defaultVarTypos, opts.VarNameTypo.Typos)),
+		"unwanted file":    newUnwantedFileChecker(mergeOptions(defaultUnwantedFilePatterns, opts.UnwantedFile.Patterns)),
 		"sloppy copyright": newSloppyCopyrightChecker(),
-		"acronym":          newAcronymChecker(),
+		"acronym":          newAcronymChecker(mergeOptions(defaultAcronyms, opts.Acronym.Acronyms)),
 		"code snippet":     &codeSnippetChecker{},
-		"readme badge":     &badgeChecker{user: l.user},
+		"readme badge":     &badgeChecker{},
 		"travis lint":      &travisChecker{},
 	}
-	return nil
 }
 
-func (l *linter) initClient() error {
+// checkerNames returns sorted enabled checker names.
+func (l *linter) checkerNames() []string {
+	names := make([]string, 0, len(l.checkers))
+	for name := range l.checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// reportedCheckers returns sorted names of the enabled checkers
+// and the checkers that have warnings in counts. A repository
+// .repolint.yml can enable checkers that are disabled globally.
+func (l *linter) reportedCheckers(counts map[string]int) []string {
+	names := l.checkerNames()
+	for name := range counts {
+		if _, ok := l.checkers[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newRepoCheckers returns a fresh set of enabled checkers configured by cfg.
+// Checkers keep per-repository state, so every goroutine
+// that checks repositories needs its own set.
+//
+// If repoCfg is not nil, it's a repository config that can
+// enable or disable checkers for that repository.
+func (l *linter) newRepoCheckers(cfg, repoCfg *config) map[string]fileChecker {
+	checkers := newCheckers(cfg)
+	for name := range checkers {
+		_, enabled := l.checkers[name]
+		if repoCfg != nil && !l.flagDisabled[name] {
+			enabled = repoCfg.enabled(name, enabled)
+		}
+		if !enabled {
+			delete(checkers, name)
+		}
+	}
+	return checkers
+}
+
+func (l *linter) initProvider() error {
 	l.ctx = context.Background()
+	l.requests = newRequestCounter()
+	if l.localRepo() != "" {
+		return nil
+	}
 
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: l.token})
-	tc := oauth2.NewClient(l.ctx, ts)
-	l.client = github.NewClient(tc)
+	var transport http.RoundTripper = &rateLimitTransport{
+		base:       http.DefaultTransport,
+		requests:   l.requests,
+		maxRetries: 5,
+	}
+	if l.cacheDir != "" {
+		cache, err := newDiskCache(l.cacheDir)
+		if err != nil {
+			return fmt.Errorf("init cache: %v", err)
+		}
+		l.cache = cache
+		transport = &etagTransport{base: transport, cache: cache}
+	}
+	client := &http.Client{Transport: transport}
+	p, err := newProvider(l.ctx, client, l.providerKind, l.baseURL, l.token, l.verbose)
+	l.provider = p
+	return err
+}
 
+func (l *linter) initSource() error {
+	if l.dir != "" {
+		root, err := filepath.Abs(l.dir)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(root)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", l.dir)
+		}
+		l.source = &dirSource{root: root}
+		return nil
+	}
+
+	if l.gitDir != "" {
+		src := newGitSource(l.tempDir, "")
+		if err := src.openLocal(l.gitDir); err != nil {
+			return err
+		}
+		l.source = src
+		return nil
+	}
+
+	if l.clone {
+		if err := checkFetchRef(l.ref); err != nil {
+			return fmt.Errorf("-ref: %v", err)
+		}
+		l.source = newGitSource(l.tempDir, gitAuthHeader(l.providerKind, l.token))
+		return nil
+	}
+
+	l.source = l.provider
+	if l.cache != nil {
+		l.source = &cachedSource{repoSource: l.provider, cache: l.cache}
+	}
 	return nil
 }
 
 func (l *linter) getReposList() error {
+	if l.localRepo() != "" {
+		// Local mode has no repository metadata, so it's synthesized
+		// from the directory name and the -lang flag.
+		root, err := filepath.Abs(l.localRepo())
+		if err != nil {
+			return err
+		}
+		if filepath.Base(root) == ".git" {
+			root = filepath.Dir(root)
+		}
+		name := filepath.Base(root)
+		name = strings.TrimSuffix(name, ".bundle")
+		name = strings.TrimSuffix(name, ".git")
+		l.repos = append(l.repos, &repository{
+			owner:    l.user,
+			name:     name,
+			language: l.lang,
+			stars:    -1,
+		})
+		return nil
+	}
+
 	if l.singleRepo != "" {
-		repo, _, err := l.client.Repositories.Get(l.ctx, l.user, l.singleRepo)
-		l.requests++
+		repo, err := l.provider.getRepo(l.user, l.singleRepo)
 		if err != nil {
 			return fmt.Errorf("get repo %s: %v", l.singleRepo, err)
 		}
+		l.fetchLanguage(repo)
 		l.repos = append(l.repos, repo)
 		return nil
 	}
 
-	opts := newRepositoryListOptions()
-	for {
-		repos, resp, err := l.client.Repositories.List(l.ctx, l.user, opts)
-		l.requests++
-		if err != nil {
-			if resp.NextPage == 0 && opts.Page > 1 {
-				// Ignore last page list error.
-				return nil
+	repos, err := l.provider.listRepos(l.user)
+	if err != nil {
+		return err
+	}
+	if l.verbose {
+		log.Printf("\t\tdebug: fetched %d repo names\n", len(repos))
+	}
+	for _, repo := range repos {
+		if l.skipForks && repo.fork {
+			if l.verbose {
+				log.Printf("\t\tdebug: skip %s repo (fork)", repo.name)
 			}
-			return fmt.Errorf("list repos (page=%d): %v", opts.Page, err)
-		}
-
-		if l.verbose {
-			log.Printf("\t\tdebug: fetched %d repo names\n", len(repos))
+			continue
 		}
-		for _, repo := range repos {
-			if l.skipForks && *repo.Fork {
-				if l.verbose {
-					log.Printf("\t\tdebug: skip %s repo (fork)", *repo.Name)
-				}
-				continue
-			}
-			if l.skipArchived && *repo.Archived {
-				if l.verbose {
-					log.Printf("\t\tdebug: skip %s repo (archived)", *repo.Name)
-				}
-				continue
+		if l.skipArchived && repo.archived {
+			if l.verbose {
+				log.Printf("\t\tdebug: skip %s repo (archived)", repo.name)
 			}
-			if *repo.StargazersCount < l.minStars {
-				if l.verbose {
-					log.Printf("\t\tdebug: skip %s repo (not enough stars)", *repo.Name)
-				}
-				continue
+			continue
+		}
+		if repo.stars != -1 && repo.stars < l.minStars {
+			if l.verbose {
+				log.Printf("\t\tdebug: skip %s repo (not enough stars)", repo.name)
 			}
+			continue
+		}
 
-			const montsToExpire = 6
-			const hoursToExpire = montsToExpire * 32 * 24
-			inactive := time.Since(repo.GetPushedAt().Time).Hours() > hoursToExpire
-			if l.skipInactive && inactive {
-				if l.verbose {
-					log.Printf("\t\tdebug: skip %s repo (inactive)", *repo.Name)
-				}
-				continue
+		const montsToExpire = 6
+		const hoursToExpire = montsToExpire * 32 * 24
+		inactive := !repo.pushedAt.IsZero() &&
+			time.Since(repo.pushedAt).Hours() > hoursToExpire
+		if l.skipInactive && inactive {
+			if l.verbose {
+				log.Printf("\t\tdebug: skip %s repo (inactive)", repo.name)
 			}
+			continue
+		}
 
-			if l.lang != "" && repo.GetLanguage() != l.lang {
-				if l.verbose {
-					log.Printf("\t\tdebug: skip %s repo (lang filter)", *repo.Name)
-				}
-				continue
+		// Languages can require a request per repository,
+		// so they're fetched only for the repositories that passed other filters.
+		l.fetchLanguage(repo)
+		if l.lang != "" && repo.language != l.lang {
+			if l.verbose {
+				log.Printf("\t\tdebug: skip %s repo (lang filter)", repo.name)
 			}
-
-			l.repos = append(l.repos, repo)
+			continue
 		}
 
-		if resp.NextPage == 0 {
-			break
-		}
-		opts.Page = resp.NextPage
+		l.repos = append(l.repos, repo)
 	}
 
 	return nil
 }
 
+// fetchLanguage sets the repo language if the provider reports it separately.
+// Errors are logged, the repo language stays unknown then.
+func (l *linter) fetchLanguage(repo *repository) {
+	p, ok := l.provider.(languageProvider)
+	if !ok {
+		return
+	}
+	lang, err := p.repoLanguage(repo)
+	if err != nil {
+		log.Printf("\terror: get %s languages: %v", repo.fullName(), err)
+		return
+	}
+	repo.language = lang
+}
+
 func (l *linter) disableCheckers() error {
+	disabled := make(map[string]bool)
 	for _, name := range strings.Split(l.disable, ",") {
-		name = strings.TrimSpace(name)
-		delete(l.checkers, name)
+		disabled[strings.TrimSpace(name)] = true
+	}
+	l.flagDisabled = make(map[string]bool)
+	if l.explicitFlags["disable"] {
+		l.flagDisabled = disabled
+	}
+	for name := range l.checkers {
+		enabled := !disabled[name]
+		if !l.flagDisabled[name] {
+			enabled = l.config.enabled(name, enabled)
+		}
+		if !enabled {
+			delete(l.checkers, name)
+		}
+	}
+	return nil
+}
+
+func (l *linter) loadCheckpoint() error {
+	if !l.resume {
+		l.state = newCheckpoint(l.user)
+		return nil
+	}
+
+	state, err := loadCheckpoint(l.statePath)
+	if err != nil {
+		return err
 	}
+	if state.User != l.user {
+		return fmt.Errorf("%s checkpoint is for %q, not %q", l.statePath, state.User, l.user)
+	}
+	l.state = state
+	log.Printf("\tresuming: %d repositories done, %d failed, %d warnings printed, %d requests made",
+		len(state.Done), len(state.Failed), state.Warnings, state.Requests)
 	return nil
 }
 
+// repoError is a lintRepo error that doesn't stop other repositories check.
+type repoError struct {
+	err error
+}
+
+func (e *repoError) Error() string { return e.err.Error() }
+
+func (e *repoError) Unwrap() error { return e.err }
+
+// isRepoError reports whether err only affects a single repository.
+// Rate limit errors would affect all remaining repositories.
+func isRepoError(err error) bool {
+	_, ok := err.(*repoError)
+	return ok && !isRateLimitError(err)
+}
+
+// lintResult is a single repository lintRepo result.
+type lintResult struct {
+	warnings []warning
+	err      error
+}
+
 func (l *linter) lintRepos() error {
-	for i := l.offset; i < len(l.repos); i++ {
-		repo := l.repos[i]
-		log.Printf("\tchecking %s/%s (%d/%d, made %d requests so far) ...",
-			l.user, *repo.Name, i+1, len(l.repos), l.requests)
-		if err := l.lintRepo(repo); err != nil {
+	var repos []*repository
+	for _, repo := range l.repos {
+		if !l.state.isDone(repo) {
+			repos = append(repos, repo)
+		}
+	}
+	skipped := len(l.repos) - len(repos)
+	requestsBefore := l.state.Requests
+
+	// Repositories are checked concurrently, but their
+	// results are printed in the repos list order.
+	results := make([]chan lintResult, len(repos))
+	for i := range results {
+		results[i] = make(chan lintResult, 1)
+	}
+	queue := make(chan int)
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < l.jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkers := l.newRepoCheckers(l.config, nil)
+			for i := range queue {
+				repo := repos[i]
+				if l.localRepo() != "" {
+					log.Printf("\tchecking %s ...", l.localRepo())
+				} else {
+					log.Printf("\tchecking %s (%d/%d, %s) ...",
+						repo.fullName(), skipped+i+1, len(l.repos), l.requests)
+				}
+				warnings, err := l.lintRepo(repo, checkers)
+				results[i] <- lintResult{warnings: warnings, err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(queue)
+		for i := range repos {
+			select {
+			case queue <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var err error
+	var summary runSummary
+	failed := 0
+	for i, ch := range results {
+		res := <-ch
+		if res.err != nil && !isRepoError(res.err) {
+			err = res.err
+			break
+		}
+		if res.err != nil {
+			// Failed repositories are not marked as done,
+			// so they're checked again on resume.
+			log.Printf("\terror: %v", res.err)
+			l.state.markFailed(repos[i])
+			failed++
+		} else {
+			if err = l.reportRepo(repos[i], res.warnings, &summary); err != nil {
+				break
+			}
+		}
+		l.state.Requests = requestsBefore + l.requests.requests()
+		if l.statePath == "" {
+			continue
+		}
+		if err = l.state.save(l.statePath); err != nil {
+			err = fmt.Errorf("save checkpoint: %v", err)
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	summary.requests = l.requests.requests()
+	if l.baselineWriter != nil {
+		if err := l.baselineWriter.finish(&summary); err != nil {
+			return fmt.Errorf("write baseline: %v", err)
+		}
+	}
+	if err := l.reporter.finish(&summary); err != nil {
+		return err
+	}
+
+	log.Printf("\twarnings by severity: %d error, %d warning, %d info",
+		summary.severities[severityError],
+		summary.severities[severityWarning],
+		summary.severities[severityInfo])
+	if failed != 0 {
+		log.Printf("\t%d repositories failed to be checked", failed)
+	}
+	if l.failOn != "" {
+		// Repositories that failed to be checked can have
+		// any warnings, so the gate fails closed.
+		if failed != 0 {
+			l.failed = true
+		}
+		// Already validated by parseFlags.
+		failOn, _ := parseSeverity(l.failOn)
+		for s := failOn; s <= severityError; s++ {
+			if summary.severities[s] != 0 {
+				l.failed = true
+			}
+		}
+	}
+	return nil
+}
+
+// reportRepo reports the repo warnings and records its check completion.
+func (l *linter) reportRepo(repo *repository, warnings []warning, summary *runSummary) error {
+	if l.baselineWriter != nil {
+		if err := l.baselineWriter.report(repo, warnings); err != nil {
 			return err
 		}
 	}
+	if l.baseline != nil {
+		warnings = l.baseline.filter(l.repoPath(repo), warnings)
+	}
+	if err := l.reporter.report(repo, warnings); err != nil {
+		return err
+	}
+	summary.repos++
+	summary.checked = append(summary.checked, repo.fullName())
+	summary.warnings += len(warnings)
+	for _, w := range warnings {
+		summary.severities[w.severity]++
+	}
+	l.state.markDone(repo, len(warnings))
 	return nil
 }
 
 type repoFile struct {
-	// origName is file original name as in github repo.
+	// origName is file original name as in the repository.
 	origName string
 
+	// sha is a git blob hash, if known.
+	sha string
+
+	// mode is a git file mode, if known.
+	mode string
+
 	// baseName is a filepath.Base(origName) result.
 	baseName string
 
@@ -280,30 +884,188 @@ type repoFile struct {
 	}
 }
 
-func (l *linter) lintRepo(repo *github.Repository) error {
-	files, err := l.collectRepoFiles(*repo.Name)
+// refKind returns the repo ref kind.
+// Branches are assumed if the provider can't tell tags from them.
+func (l *linter) refKind(repo *repository) string {
+	switch {
+	case repo.ref == repo.defaultBranch:
+		return refBranch
+	case commitSHARE.MatchString(repo.ref):
+		return refCommit
+	}
+	p, ok := l.provider.(tagProvider)
+	if !ok || l.localRepo() != "" {
+		return refBranch
+	}
+	isTag, err := p.hasTag(repo, repo.ref)
 	if err != nil {
-		return err
+		log.Printf("\terror: get %s %s tag: %v", repo.fullName(), repo.ref, err)
+		return refBranch
 	}
+	if isTag {
+		return refTag
+	}
+	return refBranch
+}
 
-	for _, c := range l.checkers {
+// lintRepo runs checkers over the repo and returns their warnings.
+func (l *linter) lintRepo(repo *repository, checkers map[string]fileChecker) ([]warning, error) {
+	repo.ref = repo.defaultBranch
+	repo.refKind = refBranch
+	if l.ref != "" {
+		repo.ref = l.ref
+		repo.refKind = l.refKind(repo)
+	}
+
+	files, paths, err := l.collectRepoFiles(repo)
+	if err != nil {
+		return nil, &repoError{err: err}
+	}
+	cfg := l.config
+	repoCfg, err := l.repoConfig(repo, files)
+	if err != nil {
+		log.Printf("\terror: %s: %v", repo.name, err)
+	} else if repoCfg != nil {
+		cfg = l.config.merge(repoCfg)
+		checkers = l.newRepoCheckers(cfg, repoCfg)
+	}
+	accepted := files[:0]
+	for _, f := range files {
+		if cfg.acceptFile(f.origName) {
+			accepted = append(accepted, f)
+		}
+	}
+	files = accepted
+
+	tempDir := l.repoTempDir(repo)
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			log.Printf("\terror: remove %s temp dir: %v", repo.name, err)
+		}
+		if src, ok := l.source.(*gitSource); ok {
+			src.release(repo)
+		}
+	}()
+
+	names := make([]string, 0, len(checkers))
+	for name, c := range checkers {
+		names = append(names, name)
 		c.Reset(repo)
+		if tc, ok := c.(treeChecker); ok {
+			tc.SetTree(paths)
+		}
 		for _, f := range files {
 			c.PushFile(f)
 		}
 	}
+	sort.Strings(names)
+
+	// A file that failed to be fetched would look clean,
+	// so the whole repository is reported as failed instead.
+	if err := l.resolveFiles(repo, files); err != nil {
+		return nil, &repoError{err: err}
+	}
+
+	filesByName := make(map[string]*repoFile, len(files))
 	for _, f := range files {
-		l.resolveRequirements(*repo.Name, f)
+		filesByName[f.origName] = f
 	}
-	for name, c := range l.checkers {
-		for _, warning := range c.CheckFiles() {
-			fmt.Printf("github.com/%s/%s: %s: %s\n", l.user, *repo.Name, name, warning)
+	// Linked files are only known after the pushed files are fetched.
+	for _, name := range names {
+		if tc, ok := checkers[name].(linkTargetChecker); ok {
+			tc.PushTargets(filesByName)
 		}
 	}
-	return nil
+	if err := l.resolveFiles(repo, files); err != nil {
+		return nil, &repoError{err: err}
+	}
+
+	var warnings []warning
+	for _, name := range names {
+		for _, w := range checkers[name].CheckFiles() {
+			w.checker = name
+			if f := filesByName[w.file]; f != nil && w.line != 0 {
+				w.lineText = fileLine(f.contents, w.line)
+			}
+			if s, ok := cfg.severities[name]; ok {
+				w.severity = s
+			}
+			warnings = append(warnings, w)
+		}
+	}
+	warnings = l.suppressWarnings(repo, cfg, files, warnings)
+
+	if l.fix {
+		changes := l.fixRepo(repo, files, checkers, warnings)
+		if l.openPR {
+			if err := l.openPullRequest(repo, changes); err != nil {
+				return nil, fmt.Errorf("open %s pull request: %v", repo.fullName(), err)
+			}
+		} else if err := l.writeFix(repo, changes); err != nil {
+			return nil, fmt.Errorf("fix %s: %v", repo.fullName(), err)
+		}
+	}
+
+	return warnings, nil
+}
+
+// repoConfig returns the repository root config, if any.
+// Returns nil if the config is the one that is already loaded.
+func (l *linter) repoConfig(repo *repository, files []*repoFile) (*config, error) {
+	for _, f := range files {
+		if f.origName != configFilename {
+			continue
+		}
+		if src, ok := l.source.(localSource); ok && l.configFile != "" {
+			filename, err := filepath.Abs(src.localPath(f.origName))
+			if err == nil && filename == l.configFile {
+				return nil, nil
+			}
+		}
+		cfg, err := parseConfig([]byte(l.fileContents(repo, f)))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", configFilename, err)
+		}
+		if len(cfg.Flags) != 0 && l.verbose {
+			log.Printf("\t\tdebug: %s %s flags are ignored", repo.name, configFilename)
+		}
+		return cfg, nil
+	}
+	return nil, nil
+}
+
+// repoTempDir returns a directory for the repo files local copies.
+func (l *linter) repoTempDir(repo *repository) string {
+	return filepath.Join(l.tempDir, "files", filepath.FromSlash(repo.fullName()))
+}
+
+// localRepo returns a repository path for a local mode.
+// Empty string means that repositories are fetched from the provider.
+func (l *linter) localRepo() string {
+	if l.dir != "" {
+		return l.dir
+	}
+	return l.gitDir
+}
+
+// repoPath returns a repository location that is used to prefix warnings.
+func (l *linter) repoPath(repo *repository) string {
+	if l.localRepo() != "" {
+		return l.localRepo()
+	}
+	u, err := url.Parse(repo.webURL)
+	if err != nil || u.Host == "" {
+		return repo.fullName()
+	}
+	return u.Host + "/" + repo.fullName()
 }
 
-func (l *linter) collectRepoFiles(repo string) ([]*repoFile, error) {
+// collectRepoFiles returns the repo files that should be checked
+// and all repo file paths, including the vendored ones.
+func (l *linter) collectRepoFiles(repo *repository) (files []*repoFile, paths []string, err error) {
 	vendorDirs := []string{
 		`/?vendor/`,
 		`/?node_modules/`,
@@ -311,50 +1073,88 @@ func (l *linter) collectRepoFiles(repo string) ([]*repoFile, error) {
 		`/?third[-_]party/`,
 	}
 	vendorRE := regexp.MustCompile(strings.Join(vendorDirs, "|"))
-	tree, _, err := l.client.Git.GetTree(l.ctx, l.user, repo, "master", true)
-	l.requests++
+	entries, err := l.source.getTree(repo)
 	if err != nil {
-		if strings.Contains(err.Error(), "API rate limit") {
-			return nil, err
-		}
-		log.Printf("\terror: get %s tree: %v", repo, err)
-		return nil, nil
-	}
-	if l.verbose && *tree.Truncated {
-		log.Printf("\t\tdebug: %s tree is truncated", repo)
+		return nil, nil, fmt.Errorf("get %s tree: %w", repo.fullName(), err)
 	}
 
-	var files []*repoFile
-	for _, entry := range tree.Entries {
-		if entry.Path == nil {
-			continue
-		}
-		if l.skipVendor && vendorRE.MatchString(*entry.Path) {
+	for _, entry := range entries {
+		paths = append(paths, entry.path)
+		if l.skipVendor && vendorRE.MatchString(entry.path) {
 			continue
 		}
 		files = append(files, &repoFile{
-			origName: *entry.Path,
-			baseName: filepath.Base(*entry.Path),
+			origName: entry.path,
+			baseName: filepath.Base(entry.path),
+			sha:      entry.sha,
+			mode:     entry.mode,
 		})
 	}
 
-	return files, nil
+	return files, paths, nil
 }
 
-func (l *linter) resolveRequirements(repo string, f *repoFile) {
+// resolveFiles resolves files requirements concurrently.
+// It returns the first file that failed to be fetched error.
+func (l *linter) resolveFiles(repo *repository, files []*repoFile) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	for _, f := range files {
+		if !f.require.localCopy && !f.require.contents {
+			continue
+		}
+		wg.Add(1)
+		l.fetchLimit <- struct{}{}
+		go func(f *repoFile) {
+			defer func() {
+				<-l.fetchLimit
+				wg.Done()
+			}()
+			if err := l.resolveRequirements(repo, f); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(f)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func (l *linter) resolveRequirements(repo *repository, f *repoFile) error {
 	if f.require.contents {
 		f.require.localCopy = true
 	}
 
 	if f.require.localCopy && f.tempName == "" {
-		l.createLocalCopy(repo, f)
+		return l.createLocalCopy(repo, f)
 	}
+	return nil
 }
 
-func (l *linter) createLocalCopy(repo string, f *repoFile) {
+func (l *linter) createLocalCopy(repo *repository, f *repoFile) error {
+	if src, ok := l.source.(localSource); ok {
+		// Files are already on disk, use them directly.
+		f.tempName = src.localPath(f.origName)
+		if f.require.contents {
+			data, err := l.getContents(repo, f)
+			if err != nil {
+				return err
+			}
+			f.contents = data
+		}
+		return nil
+	}
+
 	flatPath := strings.Replace(f.origName, "/", "_(slash)_", -1)
-	filename := filepath.Join(l.tempDir, flatPath)
-	data := l.getContents(repo, f.origName)
+	filename := filepath.Join(l.repoTempDir(repo), flatPath)
+	data, err := l.getContents(repo, f)
+	if err != nil {
+		return err
+	}
 	if f.require.contents {
 		f.contents = data
 	}
@@ -362,30 +1162,13 @@ func (l *linter) createLocalCopy(repo string, f *repoFile) {
 		panic(fmt.Sprintf("write %s: %v", f.origName, err))
 	}
 	f.tempName = filename
+	return nil
 }
 
-func (l *linter) getContents(repo, path string) string {
-	f, _, _, err := l.client.Repositories.GetContents(l.ctx, l.user, repo, path, nil)
-	l.requests++
+func (l *linter) getContents(repo *repository, f *repoFile) (string, error) {
+	s, err := l.source.getBlob(repo, treeEntry{path: f.origName, sha: f.sha})
 	if err != nil {
-		log.Printf("\terror: get %s/%s contents: %v", repo, path, err)
-		return ""
-	}
-	if f == nil {
-		log.Printf("\terror: %s/%s contents is nil", repo, path)
-		return ""
-	}
-	s, err := f.GetContent()
-	if err != nil {
-		panic(fmt.Sprintf("get %s contents: %v", path, err))
-	}
-	return s
-}
-
-func newRepositoryListOptions() *github.RepositoryListOptions {
-	// Use some high value, github will limit it anyway,
-	// but we're interested in getting more data per one request.
-	return &github.RepositoryListOptions{
-		ListOptions: github.ListOptions{PerPage: math.MaxInt32},
+		return "", fmt.Errorf("get %s/%s contents: %w", repo.name, f.origName, err)
 	}
+	return s, nil
 }
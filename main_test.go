@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// roundTripFunc is an http.RoundTripper that calls itself.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// stubHTTPClient makes the shared httpClient reply 200 to every request
+// and returns the list of requested URLs.
+func stubHTTPClient(t *testing.T) *[]string {
+	var urls []string
+	transport := httpClient.Transport
+	httpClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		urls = append(urls, req.URL.String())
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	})
+	t.Cleanup(func() { httpClient.Transport = transport })
+	return &urls
+}
+
+func TestLintRepoRef(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"README.md":   "# r\n",
+		".travis.yml": "language: go\n",
+	})
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"", "https://travis-ci.org/o/r.svg?branch=main"},
+		{"v2", "https://travis-ci.org/o/r.svg?branch=v2"},
+	}
+	for _, test := range tests {
+		urls := stubHTTPClient(t)
+		l := &linter{
+			ref:        test.ref,
+			tempDir:    t.TempDir(),
+			source:     &dirSource{root: root},
+			fetchLimit: make(chan struct{}, 1),
+			config:     &config{},
+		}
+		repo := &repository{owner: "o", name: "r", defaultBranch: "main"}
+		checkers := map[string]fileChecker{"readme badge": &badgeChecker{}}
+		if _, err := l.lintRepo(repo, checkers); err != nil {
+			t.Fatalf("lintRepo: %v", err)
+		}
+		if len(*urls) != 1 || (*urls)[0] != test.want {
+			t.Errorf("ref %q: got %q badge requests, want %q", test.ref, *urls, test.want)
+		}
+	}
+}
+
+// delayedSource is a repoSource that serves a single Vim swap file
+// per repository after the repository delay.
+type delayedSource map[string]time.Duration
+
+func (s delayedSource) getTree(repo *repository) ([]treeEntry, error) {
+	time.Sleep(s[repo.name])
+	return []treeEntry{{path: "." + repo.name + ".swp"}}, nil
+}
+
+func (s delayedSource) getBlob(repo *repository, entry treeEntry) (string, error) {
+	return "", nil
+}
+
+// newTestReporter returns a text reporter that writes to buf.
+func newTestReporter(buf *bytes.Buffer) reporter {
+	return &textReporter{w: buf, repoPath: (*repository).fullName}
+}
+
+func TestLintReposOrder(t *testing.T) {
+	// Later repositories are checked faster, but the
+	// warnings are still printed in the repositories order.
+	source := delayedSource{"a": 60 * time.Millisecond, "b": 30 * time.Millisecond, "c": 0}
+	l := &linter{
+		user:       "o",
+		jobs:       3,
+		tempDir:    t.TempDir(),
+		source:     source,
+		requests:   newRequestCounter(),
+		state:      newCheckpoint("o"),
+		fetchLimit: make(chan struct{}, 3),
+		checkers:   map[string]fileChecker{"unwanted file": nil},
+		config:     &config{},
+	}
+	var out bytes.Buffer
+	l.reporter = newTestReporter(&out)
+	for _, name := range []string{"a", "b", "c"} {
+		l.repos = append(l.repos, &repository{owner: "o", name: name})
+	}
+
+	if err := l.lintRepos(); err != nil {
+		t.Fatalf("lintRepos: %v", err)
+	}
+	want := "o/a: unwanted file: .a.swp: remove Vim swap file\n" +
+		"o/b: unwanted file: .b.swp: remove Vim swap file\n" +
+		"o/c: unwanted file: .c.swp: remove Vim swap file\n"
+	if out.String() != want {
+		t.Errorf("lintRepos output:\nhave: %q\nwant: %q", out.String(), want)
+	}
+}
+
+// swapSource is a repoSource that serves a Vim swap file per repository
+// and records the checked repositories. Repositories listed in errs
+// fail with the specified errors.
+type swapSource struct {
+	mu      sync.Mutex
+	checked []string
+	errs    map[string]error
+}
+
+func (s *swapSource) getTree(repo *repository) ([]treeEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checked = append(s.checked, repo.name)
+	if err := s.errs[repo.name]; err != nil {
+		return nil, err
+	}
+	return []treeEntry{{path: "." + repo.name + ".swp"}}, nil
+}
+
+func (s *swapSource) getBlob(repo *repository, entry treeEntry) (string, error) {
+	return "", nil
+}
+
+func newTestResumeLinter(t *testing.T, source repoSource, out *bytes.Buffer, statePath string, names ...string) *linter {
+	l := &linter{
+		user:       "o",
+		jobs:       2,
+		tempDir:    t.TempDir(),
+		source:     source,
+		statePath:  statePath,
+		requests:   newRequestCounter(),
+		fetchLimit: make(chan struct{}, 2),
+		checkers:   map[string]fileChecker{"unwanted file": nil},
+		reporter:   newTestReporter(out),
+		config:     &config{},
+	}
+	for _, name := range names {
+		l.repos = append(l.repos, &repository{owner: "o", name: name})
+	}
+	return l
+}
+
+func TestLintReposResume(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	// The first run is interrupted after a, b and c repositories,
+	// but c tree couldn't be fetched.
+	first := &swapSource{errs: map[string]error{"c": errors.New("connection reset")}}
+	var out bytes.Buffer
+	l := newTestResumeLinter(t, first, &out, statePath, "a", "b", "c")
+	if err := l.loadCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		l.requests.add(nil)
+	}
+	if err := l.lintRepos(); err != nil {
+		t.Fatalf("lintRepos: %v", err)
+	}
+	state, err := loadCheckpoint(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(state.Done, []string{"o/a", "o/b"}) || !reflect.DeepEqual(state.Failed, []string{"o/c"}) {
+		t.Errorf("checkpoint: got %q done and %q failed, want o/a, o/b done and o/c failed",
+			state.Done, state.Failed)
+	}
+
+	// The resumed run has a different repositories order and a new repository.
+	second := &swapSource{}
+	out.Reset()
+	l = newTestResumeLinter(t, second, &out, statePath, "d", "c", "b", "a")
+	l.resume = true
+	if err := l.loadCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	l.requests.add(nil)
+	if err := l.lintRepos(); err != nil {
+		t.Fatalf("lintRepos: %v", err)
+	}
+	want := "o/d: unwanted file: .d.swp: remove Vim swap file\n" +
+		"o/c: unwanted file: .c.swp: remove Vim swap file\n"
+	if out.String() != want {
+		t.Errorf("resumed output:\nhave: %q\nwant: %q", out.String(), want)
+	}
+	sort.Strings(second.checked)
+	if !reflect.DeepEqual(second.checked, []string{"c", "d"}) {
+		t.Errorf("resumed run checked %q, want only c and d", second.checked)
+	}
+
+	state, err = loadCheckpoint(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Done) != 4 || len(state.Failed) != 0 || state.Warnings != 4 || state.Requests != 4 {
+		t.Errorf("checkpoint: got %d done, %d failed, %d warnings, %d requests, want 4, 0, 4 and 4",
+			len(state.Done), len(state.Failed), state.Warnings, state.Requests)
+	}
+}
+
+func TestLintReposFailOn(t *testing.T) {
+	tests := []struct {
+		failOn     string
+		severity   string
+		fetchError bool
+		failed     bool
+	}{
+		{failOn: "", failed: false},
+		{failOn: "error", failed: false},
+		{failOn: "warning", failed: true},
+		{failOn: "info", failed: true},
+		{failOn: "error", severity: "error", failed: true},
+		{failOn: "warning", severity: "info", failed: false},
+		// Repositories that failed to be checked fail the run.
+		{failOn: "error", severity: "info", fetchError: true, failed: true},
+		{failOn: "", fetchError: true, failed: false},
+	}
+
+	for _, test := range tests {
+		source := &swapSource{}
+		if test.fetchError {
+			source.errs = map[string]error{"b": errors.New("503 Service Unavailable")}
+		}
+		var out bytes.Buffer
+		l := newTestResumeLinter(t, source, &out, "", "a", "b")
+		l.state = newCheckpoint("o")
+		l.failOn = test.failOn
+		if test.severity != "" {
+			cfg, err := parseConfig([]byte("severity: {unwanted file: " + test.severity + "}\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.config = cfg
+		}
+		if err := l.lintRepos(); err != nil {
+			t.Fatalf("lintRepos: %v", err)
+		}
+		if l.failed != test.failed {
+			t.Errorf("failOn=%q severity=%q fetchError=%v: got failed=%v, want %v",
+				test.failOn, test.severity, test.fetchError, l.failed, test.failed)
+		}
+	}
+}
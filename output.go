@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// reporter prints lint results in some output format.
+type reporter interface {
+	// report is called for every checked repository,
+	// in the repositories list order.
+	report(repo *repository, warnings []warning) error
+
+	// finish is called after all repositories are checked.
+	finish(s *runSummary) error
+}
+
+// runSummary is a lint run totals.
+type runSummary struct {
+	repos    int
+	warnings int
+	requests int
+
+	// checked is the checked repositories full names.
+	checked []string
+
+	// severities are warnings counts indexed by their severity.
+	severities [severityError + 1]int
+}
+
+// newReporter returns a reporter for the specified output format.
+// Linter is used to get the repositories locations and the enabled checkers.
+func newReporter(format string, w io.Writer, l *linter) (reporter, error) {
+	switch format {
+	case "text":
+		return &textReporter{w: w, repoPath: l.repoPath}, nil
+	case "json":
+		return &jsonReporter{w: w}, nil
+	case "jsonl":
+		return &jsonReporter{w: w, lines: true}, nil
+	case "sarif":
+		return &sarifReporter{w: w, l: l}, nil
+	case "checkstyle":
+		return &checkstyleReporter{w: w, l: l}, nil
+	case "junit":
+		return &junitReporter{w: w, l: l}, nil
+	case "tasks":
+		return &tasksReporter{w: w, l: l}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// multiReporter passes lint results to all of its reporters.
+type multiReporter []reporter
+
+func (rs multiReporter) report(repo *repository, warnings []warning) error {
+	for _, r := range rs {
+		if err := r.report(repo, warnings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rs multiReporter) finish(s *runSummary) error {
+	for _, r := range rs {
+		if err := r.finish(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// textReporter prints "repo: checker: warning" lines.
+type textReporter struct {
+	w        io.Writer
+	repoPath func(*repository) string
+}
+
+func (r *textReporter) report(repo *repository, warnings []warning) error {
+	for _, w := range warnings {
+		_, err := fmt.Fprintf(r.w, "%s: %s: %s\n", r.repoPath(repo), w.checker, w.String())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *textReporter) finish(s *runSummary) error { return nil }
+
+// jsonRecord is a single JSON output record.
+// Type is either "warning" or "summary".
+type jsonRecord struct {
+	Type string `json:"type"`
+
+	Repo       string `json:"repo,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Checker    string `json:"checker,omitempty"`
+	File       string `json:"file,omitempty"`
+	Line       int    `json:"line,omitempty"`
+	Column     int    `json:"column,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Severity   string `json:"severity,omitempty"`
+	Suggestion string `json:"suggestion,omitempty"`
+
+	// Repository metadata that is used for filtering.
+	// Omitted if the provider doesn't report it.
+	Stars    *int       `json:"stars,omitempty"`
+	Language string     `json:"language,omitempty"`
+	PushedAt *time.Time `json:"pushed_at,omitempty"`
+
+	Repos    *int `json:"repos,omitempty"`
+	Warnings *int `json:"warnings,omitempty"`
+	Requests *int `json:"requests,omitempty"`
+
+	// Severities maps severity names to the warnings counts.
+	Severities map[string]int `json:"severities,omitempty"`
+
+	// Checked is the checked repositories full names,
+	// including the ones without warnings.
+	Checked []string `json:"checked,omitempty"`
+}
+
+// jsonReporter prints JSON records.
+//
+// In lines mode, every record is printed on its own line as soon as
+// the repository is checked. Otherwise, all records are collected
+// and printed as a single JSON array in the end.
+type jsonReporter struct {
+	w       io.Writer
+	lines   bool
+	records []*jsonRecord
+}
+
+func (r *jsonReporter) report(repo *repository, warnings []warning) error {
+	for _, w := range warnings {
+		rec := &jsonRecord{
+			Type:       "warning",
+			Repo:       repo.fullName(),
+			URL:        repo.webURL,
+			Checker:    w.checker,
+			File:       w.file,
+			Line:       w.line,
+			Column:     w.col,
+			Message:    w.message,
+			Severity:   w.severity.String(),
+			Suggestion: w.suggestion,
+			Language:   repo.language,
+		}
+		if repo.stars != -1 {
+			stars := repo.stars
+			rec.Stars = &stars
+		}
+		if !repo.pushedAt.IsZero() {
+			pushedAt := repo.pushedAt
+			rec.PushedAt = &pushedAt
+		}
+		if err := r.add(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *jsonReporter) finish(s *runSummary) error {
+	severities := make(map[string]int, len(s.severities))
+	for level, n := range s.severities {
+		severities[severity(level).String()] = n
+	}
+	err := r.add(&jsonRecord{
+		Type:       "summary",
+		Repos:      &s.repos,
+		Warnings:   &s.warnings,
+		Requests:   &s.requests,
+		Severities: severities,
+		Checked:    s.checked,
+	})
+	if err != nil || r.lines {
+		return err
+	}
+	data, err := json.MarshalIndent(r.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(r.w, "%s\n", data)
+	return err
+}
+
+func (r *jsonReporter) add(rec *jsonRecord) error {
+	if !r.lines {
+		r.records = append(r.records, rec)
+		return nil
+	}
+	return json.NewEncoder(r.w).Encode(rec)
+}
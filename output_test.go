@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testReportRun(t *testing.T, r reporter) {
+	t.Helper()
+	pushedAt := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	repos := []*repository{
+		{owner: "o", name: "a", webURL: "https://github.com/o/a", stars: 10, language: "Go", pushedAt: pushedAt},
+		{owner: "o", name: "b", webURL: "https://github.com/o/b", stars: -1},
+	}
+	warnings := [][]warning{
+		{{
+			checker:    "misspell",
+			file:       "README.md",
+			line:       3,
+			col:        5,
+			message:    `"teh" is a misspelling of "the"`,
+			severity:   severityInfo,
+			suggestion: "the",
+		}},
+		{{checker: "unwanted file", message: "no README", severity: severityWarning}},
+	}
+	for i, repo := range repos {
+		if err := r.report(repo, warnings[i]); err != nil {
+			t.Fatalf("report %s: %v", repo.fullName(), err)
+		}
+	}
+	s := &runSummary{repos: 2, warnings: 2, requests: 7}
+	s.severities[severityInfo] = 1
+	s.severities[severityWarning] = 1
+	if err := r.finish(s); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+}
+
+var wantJSONRecords = []string{
+	`{"type":"warning","repo":"o/a","url":"https://github.com/o/a","checker":"misspell","file":"README.md","line":3,"column":5,"message":"\"teh\" is a misspelling of \"the\"","severity":"info","suggestion":"the","stars":10,"language":"Go","pushed_at":"2018-05-01T12:00:00Z"}`,
+	`{"type":"warning","repo":"o/b","url":"https://github.com/o/b","checker":"unwanted file","message":"no README","severity":"warning"}`,
+	`{"type":"summary","repos":2,"warnings":2,"requests":7,"severities":{"error":0,"info":1,"warning":1}}`,
+}
+
+func TestJSONLinesReporter(t *testing.T) {
+	var buf bytes.Buffer
+	testReportRun(t, &jsonReporter{w: &buf, lines: true})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(wantJSONRecords) {
+		t.Fatalf("got %d records, want %d:\n%s", len(lines), len(wantJSONRecords), buf.String())
+	}
+	for i, line := range lines {
+		if line != wantJSONRecords[i] {
+			t.Errorf("record %d:\nhave: %s\nwant: %s", i, line, wantJSONRecords[i])
+		}
+	}
+}
+
+func TestJSONReporter(t *testing.T) {
+	var buf bytes.Buffer
+	r := &jsonReporter{w: &buf}
+	testReportRun(t, r)
+
+	var records []json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, buf.String())
+	}
+	if len(records) != len(wantJSONRecords) {
+		t.Fatalf("got %d records, want %d", len(records), len(wantJSONRecords))
+	}
+	for i, rec := range records {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, rec); err != nil {
+			t.Fatal(err)
+		}
+		if compact.String() != wantJSONRecords[i] {
+			t.Errorf("record %d:\nhave: %s\nwant: %s", i, compact.String(), wantJSONRecords[i])
+		}
+	}
+}
+
+func TestTextReporter(t *testing.T) {
+	var buf bytes.Buffer
+	testReportRun(t, &textReporter{w: &buf, repoPath: (*repository).fullName})
+
+	want := "o/a: misspell: README.md:3:5: \"teh\" is a misspelling of \"the\"\n" +
+		"o/b: unwanted file: no README\n"
+	if buf.String() != want {
+		t.Errorf("text output:\nhave: %q\nwant: %q", buf.String(), want)
+	}
+}
+
+func TestCheckstyleReporter(t *testing.T) {
+	var buf bytes.Buffer
+	l := &linter{}
+	testReportRun(t, &checkstyleReporter{w: &buf, l: l})
+
+	want := xml.Header + `<checkstyle version="5.0">
+  <file name="github.com/o/a/README.md">
+    <error line="3" column="5" severity="info" message="&#34;teh&#34; is a misspelling of &#34;the&#34;" source="repolint.misspell"></error>
+  </file>
+  <file name="github.com/o/b">
+    <error severity="warning" message="no README" source="repolint.unwanted file"></error>
+  </file>
+</checkstyle>
+`
+	if buf.String() != want {
+		t.Errorf("checkstyle output:\nhave: %s\nwant: %s", buf.String(), want)
+	}
+}
+
+func TestJUnitReporter(t *testing.T) {
+	var buf bytes.Buffer
+	l := &linter{checkers: map[string]fileChecker{"misspell": nil, "unwanted file": nil}}
+	testReportRun(t, &junitReporter{w: &buf, l: l})
+
+	// Every enabled checker is a test case, even if it has no warnings.
+	want := xml.Header + `<testsuites>
+  <testsuite name="github.com/o/a" tests="2" failures="1">
+    <testcase name="misspell" classname="github.com/o/a">
+      <failure message="1 warnings" type="info">README.md:3:5: &#34;teh&#34; is a misspelling of &#34;the&#34;</failure>
+    </testcase>
+    <testcase name="unwanted file" classname="github.com/o/a"></testcase>
+  </testsuite>
+  <testsuite name="github.com/o/b" tests="2" failures="1">
+    <testcase name="misspell" classname="github.com/o/b"></testcase>
+    <testcase name="unwanted file" classname="github.com/o/b">
+      <failure message="1 warnings" type="warning">no README</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+	if buf.String() != want {
+		t.Errorf("junit output:\nhave: %s\nwant: %s", buf.String(), want)
+	}
+}
+
+func TestTasksReporter(t *testing.T) {
+	var buf bytes.Buffer
+	l := &linter{user: "o", providerKind: "github"}
+	r := &tasksReporter{w: &buf, l: l}
+	repos := []*repository{
+		{owner: "o", name: "a", webURL: "https://github.com/o/a", ref: "main"},
+		{owner: "o", name: "b", webURL: "https://github.com/o/b"},
+		{owner: "o", name: "c"},
+	}
+	warnings := [][]warning{
+		{
+			{checker: "misspell", file: "README.md", line: 3, message: "teh -> the"},
+			{checker: "misspell", file: "docs/a_b.md", message: "recieve -> receive"},
+			{checker: "new checker", message: "something *odd*"},
+		},
+		nil,
+		{{checker: "missing file", message: "no LICENSE"}},
+	}
+	for i, repo := range repos {
+		if err := r.report(repo, warnings[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.finish(&runSummary{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Repositories without warnings are omitted, every checker
+	// group is a separate pull request with its own difficulty.
+	want := "# Tasks: o\n" +
+		"\n" +
+		"4 tasks in 2 repositories, estimated pull requests: 3.\n" +
+		"\n" +
+		"## [github.com/o/a](https://github.com/o/a)\n" +
+		"\n" +
+		"Estimated pull requests: 2.\n" +
+		"\n" +
+		"### misspell (easy)\n" +
+		"\n" +
+		"- [ ] [`README.md:3`](https://github.com/o/a/blob/main/README.md#L3): teh -> the\n" +
+		"- [ ] [`docs/a_b.md`](https://github.com/o/a/blob/main/docs/a_b.md): recieve -> receive\n" +
+		"\n" +
+		"### new checker (medium)\n" +
+		"\n" +
+		"- [ ] something \\*odd\\*\n" +
+		"\n" +
+		"## o/c\n" +
+		"\n" +
+		"Estimated pull requests: 1.\n" +
+		"\n" +
+		"### missing file (hard)\n" +
+		"\n" +
+		"- [ ] no LICENSE\n"
+	if buf.String() != want {
+		t.Errorf("tasks output:\nhave: %s\nwant: %s", buf.String(), want)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// pullRequest is a fix pull request to be opened.
+type pullRequest struct {
+	repo    *repository
+	changes []*fileChange
+
+	// branch is a fork branch name that is created for the fixes.
+	branch string
+
+	// dryRun disables all API calls that change something.
+	// Such calls are printed instead.
+	dryRun bool
+}
+
+const prTitle = "Fix issues found by repolint"
+
+var prBodyTemplate = template.Must(template.New("body").Parse(`This PR fixes issues found by [repolint](https://github.com/quasilyte/repolint):
+{{range .}}
+* {{.}}
+{{- end}}
+
+All changes were generated automatically, so please review them carefully.
+`))
+
+// body returns a PR description that lists the fixed warnings.
+func (pr *pullRequest) body() (string, error) {
+	var lines []string
+	for _, ch := range pr.changes {
+		for _, w := range ch.fixed {
+			lines = append(lines, w.checker+": "+w.String())
+		}
+	}
+	var buf bytes.Buffer
+	err := prBodyTemplate.Execute(&buf, lines)
+	return buf.String(), err
+}
+
+// openPullRequest forks the repository, commits the fixes using
+// Git Data API and opens a PR to the repository base branch.
+// Returns the PR web page address.
+func (p *githubProvider) openPullRequest(pr *pullRequest) (string, error) {
+	repo := pr.repo
+	body, err := pr.body()
+	if err != nil {
+		return "", err
+	}
+
+	// Read-only requests are performed even in dry run mode.
+	user, _, err := p.client.Users.Get(p.ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("get authenticated user: %v", err)
+	}
+	// Pull requests can only target branches.
+	if repo.refKind != refBranch {
+		return "", fmt.Errorf("%s is a %s, pull requests can only target branches", repo.ref, repo.refKind)
+	}
+	base, resp, err := p.client.Repositories.GetBranch(p.ctx, repo.owner, repo.name, repo.ref)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%s has no %s branch, pull requests can only target branches", repo.fullName(), repo.ref)
+	}
+	if err != nil {
+		return "", fmt.Errorf("get %s branch: %v", repo.ref, err)
+	}
+	baseCommit, _, err := p.client.Git.GetCommit(p.ctx, repo.owner, repo.name, base.GetCommit().GetSHA())
+	if err != nil {
+		return "", fmt.Errorf("get base commit: %v", err)
+	}
+
+	call := func(method, path string, fn func() error) error {
+		if pr.dryRun {
+			log.Printf("\tdry run: %s %s", method, path)
+			return nil
+		}
+		return fn()
+	}
+
+	// Repository owners can push to the repository directly.
+	head := &repository{owner: user.GetLogin(), name: repo.name}
+	if head.owner != repo.owner {
+		err := call("POST", "/repos/"+repo.fullName()+"/forks", func() error {
+			fork, _, err := p.client.Repositories.CreateFork(p.ctx, repo.owner, repo.name, nil)
+			if _, ok := err.(*github.AcceptedError); !ok && err != nil {
+				return err
+			}
+			head.owner = fork.GetOwner().GetLogin()
+			head.name = fork.GetName()
+			return p.waitFork(head, repo.ref)
+		})
+		if err != nil {
+			return "", fmt.Errorf("fork: %v", err)
+		}
+	}
+
+	treeSHA := "<tree>"
+	err = call("POST", "/repos/"+head.fullName()+"/git/trees", func() error {
+		tree, err := p.createTree(head, baseCommit.GetTree().GetSHA(), pr.changes)
+		treeSHA = tree.GetSHA()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("create tree: %v", err)
+	}
+
+	commitSHA := "<commit>"
+	err = call("POST", "/repos/"+head.fullName()+"/git/commits", func() error {
+		commit, _, err := p.client.Git.CreateCommit(p.ctx, head.owner, head.name, &github.Commit{
+			Message: github.String(prTitle),
+			Tree:    &github.Tree{SHA: github.String(treeSHA)},
+			Parents: []github.Commit{{SHA: baseCommit.SHA}},
+		})
+		commitSHA = commit.GetSHA()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("create commit: %v", err)
+	}
+
+	err = call("POST", "/repos/"+head.fullName()+"/git/refs", func() error {
+		_, _, err := p.client.Git.CreateRef(p.ctx, head.owner, head.name, &github.Reference{
+			Ref:    github.String("refs/heads/" + pr.branch),
+			Object: &github.GitObject{SHA: github.String(commitSHA)},
+		})
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("create %s branch: %v", pr.branch, err)
+	}
+
+	prURL := "<pull request>"
+	err = call("POST", "/repos/"+repo.fullName()+"/pulls", func() error {
+		pull, _, err := p.client.PullRequests.Create(p.ctx, repo.owner, repo.name, &github.NewPullRequest{
+			Title:               github.String(prTitle),
+			Head:                github.String(head.owner + ":" + pr.branch),
+			Base:                github.String(repo.ref),
+			Body:                github.String(body),
+			MaintainerCanModify: github.Bool(true),
+		})
+		prURL = pull.GetHTMLURL()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("create pull request: %v", err)
+	}
+	if pr.dryRun {
+		log.Printf("\tdry run: pull request body:\n%s", body)
+	}
+	return prURL, nil
+}
+
+// waitFork waits until a just created fork becomes available.
+// Forks are created asynchronously, it can take a few seconds.
+func (p *githubProvider) waitFork(fork *repository, branch string) error {
+	const attempts = 30
+	var err error
+	for i := 0; i < attempts; i++ {
+		_, _, err = p.client.Git.GetRef(p.ctx, fork.owner, fork.name, "heads/"+branch)
+		if err == nil {
+			return nil
+		}
+		if err := sleepContext(p.ctx, 2*time.Second); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%s fork is not ready: %v", fork.fullName(), err)
+}
+
+// createTree creates a tree with changes applied to the base tree.
+//
+// It's not implemented with GitService.CreateTree, because file deletion
+// requires a null "sha" that can't be expressed with github.TreeEntry.
+func (p *githubProvider) createTree(repo *repository, baseTree string, changes []*fileChange) (*github.Tree, error) {
+	type contentEntry struct {
+		Path    string `json:"path"`
+		Mode    string `json:"mode"`
+		Type    string `json:"type"`
+		Content string `json:"content"`
+	}
+	type deleteEntry struct {
+		Path string  `json:"path"`
+		Mode string  `json:"mode"`
+		Type string  `json:"type"`
+		SHA  *string `json:"sha"`
+	}
+
+	entries := make([]interface{}, 0, len(changes))
+	for _, ch := range changes {
+		if ch.removed {
+			entries = append(entries, deleteEntry{Path: ch.file, Mode: ch.fileMode(), Type: "blob"})
+			continue
+		}
+		entries = append(entries, contentEntry{
+			Path:    ch.file,
+			Mode:    ch.fileMode(),
+			Type:    "blob",
+			Content: ch.newContents,
+		})
+	}
+
+	path := "repos/" + repo.owner + "/" + repo.name + "/git/trees"
+	req, err := p.client.NewRequest("POST", path, map[string]interface{}{
+		"base_tree": baseTree,
+		"tree":      entries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tree := new(github.Tree)
+	if _, err := p.client.Do(p.ctx, req, tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
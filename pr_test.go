@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeGithubPR is a GitHub API stand-in that serves the requests
+// made by openPullRequest and records them.
+type fakeGithubPR struct {
+	t     *testing.T
+	calls []string
+
+	// tree is the last created tree entries.
+	tree []map[string]interface{}
+}
+
+func (f *fakeGithubPR) api() fakeAPI {
+	reply := func(method, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+			if r.Method != method {
+				f.t.Errorf("%s %s: unexpected method", r.Method, r.URL.Path)
+			}
+			if method == "POST" && strings.HasSuffix(r.URL.Path, "/forks") {
+				w.WriteHeader(http.StatusAccepted)
+			}
+			fmt.Fprint(w, body)
+		}
+	}
+	api := fakeAPI{
+		"/user":                           reply("GET", `{"login": "me"}`),
+		"/repos/octo/r/branches/main":     reply("GET", `{"name": "main", "commit": {"sha": "c0"}}`),
+		"/repos/octo/r/git/commits/c0":    reply("GET", `{"sha": "c0", "tree": {"sha": "t0"}}`),
+		"/repos/octo/r/forks":             reply("POST", `{"name": "r", "owner": {"login": "me"}}`),
+		"/repos/me/r/git/refs/heads/main": reply("GET", `{"ref": "refs/heads/main", "object": {"sha": "c0"}}`),
+		"/repos/me/r/git/commits":         reply("POST", `{"sha": "c1"}`),
+		"/repos/me/r/git/refs":            reply("POST", `{"ref": "refs/heads/repolint-fixes"}`),
+		"/repos/octo/r/pulls":             reply("POST", `{"html_url": "https://github.example/octo/r/pull/1"}`),
+	}
+	createTree := reply("POST", `{"sha": "t1"}`)
+	api["/repos/me/r/git/trees"] = func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			BaseTree string                   `json:"base_tree"`
+			Tree     []map[string]interface{} `json:"tree"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			f.t.Errorf("decode tree: %v", err)
+		}
+		if req.BaseTree != "t0" {
+			f.t.Errorf("create tree: got %q base tree, want t0", req.BaseTree)
+		}
+		f.tree = req.Tree
+		createTree(w, r)
+	}
+	return api
+}
+
+func newTestPullRequest(ref, refKind string, dryRun bool) *pullRequest {
+	return &pullRequest{
+		repo:   &repository{owner: "octo", name: "r", ref: ref, refKind: refKind},
+		branch: "repolint-fixes",
+		dryRun: dryRun,
+		changes: []*fileChange{
+			{
+				file:        "run.sh",
+				mode:        "100755",
+				oldContents: "# existance\n",
+				newContents: "# existence\n",
+				fixed: []warning{{
+					checker: "misspell",
+					file:    "run.sh",
+					line:    1,
+					col:     3,
+					message: `"existance" is a misspelling of "existence"`,
+				}},
+			},
+			{
+				file:    ".run.sh.swp",
+				removed: true,
+				fixed:   []warning{{checker: "unwanted file", file: ".run.sh.swp", message: "remove Vim swap file"}},
+			},
+		},
+	}
+}
+
+func TestOpenPullRequest(t *testing.T) {
+	fake := &fakeGithubPR{t: t}
+	p, err := newGithubProvider(context.Background(), http.DefaultClient, fake.api().start(t), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prURL, err := p.openPullRequest(newTestPullRequest("main", refBranch, false))
+	if err != nil {
+		t.Fatalf("openPullRequest: %v", err)
+	}
+	if prURL != "https://github.example/octo/r/pull/1" {
+		t.Errorf("openPullRequest: got %q URL", prURL)
+	}
+	wantCalls := []string{
+		"GET /user",
+		"GET /repos/octo/r/branches/main",
+		"GET /repos/octo/r/git/commits/c0",
+		"POST /repos/octo/r/forks",
+		"GET /repos/me/r/git/refs/heads/main",
+		"POST /repos/me/r/git/trees",
+		"POST /repos/me/r/git/commits",
+		"POST /repos/me/r/git/refs",
+		"POST /repos/octo/r/pulls",
+	}
+	if !reflect.DeepEqual(fake.calls, wantCalls) {
+		t.Errorf("openPullRequest calls:\nhave: %q\nwant: %q", fake.calls, wantCalls)
+	}
+
+	wantTree := []map[string]interface{}{
+		{"path": "run.sh", "mode": "100755", "type": "blob", "content": "# existence\n"},
+		{"path": ".run.sh.swp", "mode": "100644", "type": "blob", "sha": nil},
+	}
+	if !reflect.DeepEqual(fake.tree, wantTree) {
+		t.Errorf("created tree:\nhave: %v\nwant: %v", fake.tree, wantTree)
+	}
+}
+
+func TestOpenPullRequestDryRun(t *testing.T) {
+	fake := &fakeGithubPR{t: t}
+	p, err := newGithubProvider(context.Background(), http.DefaultClient, fake.api().start(t), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.openPullRequest(newTestPullRequest("main", refBranch, true)); err != nil {
+		t.Fatalf("openPullRequest: %v", err)
+	}
+	// Only read-only requests are made.
+	wantCalls := []string{
+		"GET /user",
+		"GET /repos/octo/r/branches/main",
+		"GET /repos/octo/r/git/commits/c0",
+	}
+	if !reflect.DeepEqual(fake.calls, wantCalls) {
+		t.Errorf("openPullRequest calls:\nhave: %q\nwant: %q", fake.calls, wantCalls)
+	}
+}
+
+func TestOpenPullRequestNotBranch(t *testing.T) {
+	tests := []struct {
+		ref     string
+		refKind string
+		want    string
+	}{
+		{"0a1b2c3d", refCommit, "0a1b2c3d is a commit, pull requests can only target branches"},
+		{"v1.0", refBranch, "octo/r has no v1.0 branch, pull requests can only target branches"},
+	}
+	for _, test := range tests {
+		fake := &fakeGithubPR{t: t}
+		p, err := newGithubProvider(context.Background(), http.DefaultClient, fake.api().start(t), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.openPullRequest(newTestPullRequest(test.ref, test.refKind, false))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("openPullRequest(%s): got %v error, want %q", test.ref, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// repoProvider is a repository hosting service API.
+type repoProvider interface {
+	repoSource
+
+	// getRepo returns a single owner repository metadata.
+	getRepo(owner, name string) (*repository, error)
+
+	// listRepos returns all owner repositories metadata.
+	listRepos(owner string) ([]*repository, error)
+}
+
+// languageProvider is implemented by providers that don't report
+// repository languages in the repositories list.
+type languageProvider interface {
+	// repoLanguage returns a repository major programming language.
+	repoLanguage(repo *repository) (string, error)
+}
+
+// tagProvider is implemented by providers that need to know
+// whether a ref is a tag to build file web page addresses.
+type tagProvider interface {
+	// hasTag reports whether the repository has a tag with the specified name.
+	hasTag(repo *repository, tag string) (bool, error)
+}
+
+// Ref kinds.
+const (
+	refBranch = "branch"
+	refTag    = "tag"
+	refCommit = "commit"
+)
+
+// commitSHARE matches abbreviated and full commit hashes.
+var commitSHARE = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// repository is a provider-neutral repository metadata.
+type repository struct {
+	owner string
+	name  string
+
+	// webURL is a repository web page address.
+	webURL string
+
+	// cloneURL is a git remote address that can be used to clone the repository.
+	cloneURL string
+
+	defaultBranch string
+
+	// ref is a git ref that is being checked.
+	// It's either a -ref flag value or a default branch name.
+	// Empty ref means that the source default should be used.
+	ref string
+
+	// refKind is a ref kind: refBranch, refTag or refCommit.
+	refKind string
+
+	// language is a repository major programming language.
+	// Empty if unknown.
+	language string
+
+	// stars is -1 if provider doesn't support stars.
+	stars int
+
+	fork     bool
+	archived bool
+
+	// pushedAt is a zero time if provider doesn't report it.
+	pushedAt time.Time
+}
+
+// fullName returns a repository name qualified by its owner name.
+func (repo *repository) fullName() string {
+	if repo.owner == "" {
+		return repo.name
+	}
+	return repo.owner + "/" + repo.name
+}
+
+// newProvider returns a repository provider of the specified kind.
+// Empty baseURL selects the provider public instance API address.
+// All API requests are performed with the given client.
+func newProvider(ctx context.Context, client *http.Client, kind, baseURL, token string, verbose bool) (repoProvider, error) {
+	switch kind {
+	case "github":
+		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+		ctx := context.WithValue(ctx, oauth2.HTTPClient, client)
+		return newGithubProvider(ctx, oauth2.NewClient(ctx, ts), baseURL, verbose)
+	case "gitlab":
+		if baseURL == "" {
+			baseURL = "https://gitlab.com/api/v4"
+		}
+		return newGitlabProvider(ctx, client, baseURL, token), nil
+	case "gitea":
+		if baseURL == "" {
+			baseURL = "https://gitea.com/api/v1"
+		}
+		return newGiteaProvider(ctx, client, baseURL, token), nil
+	case "bitbucket":
+		if baseURL == "" {
+			return nil, fmt.Errorf("bitbucket provider requires -baseURL")
+		}
+		return newBitbucketProvider(ctx, client, baseURL, token), nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q", kind)
+	}
+}
+
+// gitAuthHeader returns an HTTP Authorization header value that
+// is used to clone repositories from the specified provider.
+func gitAuthHeader(kind, token string) string {
+	if token == "" {
+		return ""
+	}
+	basic := func(user, password string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+	}
+	switch kind {
+	case "github":
+		return basic("x-access-token", token)
+	case "gitlab":
+		return basic("oauth2", token)
+	case "gitea":
+		return basic(token, "x-oauth-basic")
+	default:
+		return "Bearer " + token
+	}
+}
+
+// fileURL returns the repository file web page address.
+// If line is not 0, the address points to that line.
+// Empty string is returned if the repository has no web page.
+func fileURL(kind string, repo *repository, path string, line int) string {
+	if repo.webURL == "" {
+		return ""
+	}
+	ref := repo.ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	base := strings.TrimSuffix(repo.webURL, "/")
+	path = pathEscape(path)
+	switch kind {
+	case "gitlab":
+		u := base + "/-/blob/" + ref + "/" + path
+		if line != 0 {
+			u += "#L" + strconv.Itoa(line)
+		}
+		return u
+	case "gitea":
+		// Gitea web routes require the ref kind.
+		kind := repo.refKind
+		if kind == "" {
+			kind = refBranch
+		}
+		u := base + "/src/" + kind + "/" + ref + "/" + path
+		if line != 0 {
+			u += "#L" + strconv.Itoa(line)
+		}
+		return u
+	case "bitbucket":
+		// Web URL is already a "browse" page.
+		u := base + "/" + path + "?at=" + url.QueryEscape(ref)
+		if line != 0 {
+			u += "#" + strconv.Itoa(line)
+		}
+		return u
+	default:
+		u := base + "/blob/" + ref + "/" + path
+		if line != 0 {
+			u += "#L" + strconv.Itoa(line)
+		}
+		return u
+	}
+}
+
+// restClient is a minimal JSON API client that is used by providers
+// that have no dedicated client library.
+type restClient struct {
+	ctx     context.Context
+	client  *http.Client
+	baseURL string
+	header  http.Header
+}
+
+// httpError is returned by restClient for non-2xx responses.
+type httpError struct {
+	url    string
+	status string
+	code   int
+	body   string
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("GET %s: %s: %s", e.url, e.status, e.body)
+}
+
+// isNotFound reports whether err is a "404 Not Found" API error.
+func isNotFound(err error) bool {
+	e, ok := err.(*httpError)
+	return ok && e.code == http.StatusNotFound
+}
+
+// get returns baseURL+path response body and headers.
+func (c *restClient) get(path string) ([]byte, http.Header, error) {
+	addr := c.baseURL + path
+	req, err := http.NewRequest("GET", addr, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(c.ctx)
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.Header, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		const maxBodyLen = 200
+		if len(body) > maxBodyLen {
+			body = body[:maxBodyLen]
+		}
+		return nil, resp.Header, &httpError{
+			url:    addr,
+			status: resp.Status,
+			code:   resp.StatusCode,
+			body:   string(body),
+		}
+	}
+	return body, resp.Header, nil
+}
+
+// getJSON decodes baseURL+path response body into dst.
+func (c *restClient) getJSON(path string, dst interface{}) (http.Header, error) {
+	body, header, err := c.get(path)
+	if err != nil {
+		return header, err
+	}
+	if err := json.Unmarshal(body, dst); err != nil {
+		return header, fmt.Errorf("decode %s response: %v", path, err)
+	}
+	return header, nil
+}
+
+// majorLanguage returns a language with the biggest share.
+// Shares can be percentages, bytes or any other comparable values.
+func majorLanguage(shares map[string]float64) string {
+	lang := ""
+	max := 0.0
+	for name, share := range shares {
+		if share > max || (share == max && name < lang) {
+			lang = name
+			max = share
+		}
+	}
+	return lang
+}
+
+// pathEscape is like url.PathEscape, but keeps "/" unescaped.
+func pathEscape(path string) string {
+	u := url.URL{Path: path}
+	return u.EscapedPath()
+}
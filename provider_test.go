@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeAPI is a provider API stand-in.
+// Handlers are keyed by escaped request paths, so "owner%2Fname"
+// project paths can be told apart from the nested ones.
+type fakeAPI map[string]http.HandlerFunc
+
+func (api fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h := api[r.URL.EscapedPath()]; h != nil {
+		h(w, r)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// start runs a test server and returns its URL.
+func (api fakeAPI) start(t *testing.T) string {
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestFileURL(t *testing.T) {
+	tests := []struct {
+		kind    string
+		ref     string
+		refKind string
+		want    string
+	}{
+		{"github", "main", refBranch, "https://host/o/r/blob/main/docs/a%20b.md#L3"},
+		{"gitlab", "v1.0", refTag, "https://host/o/r/-/blob/v1.0/docs/a%20b.md#L3"},
+		{"gitea", "main", refBranch, "https://host/o/r/src/branch/main/docs/a%20b.md#L3"},
+		{"gitea", "v1.0", refTag, "https://host/o/r/src/tag/v1.0/docs/a%20b.md#L3"},
+		{"gitea", "0a1b2c3d", refCommit, "https://host/o/r/src/commit/0a1b2c3d/docs/a%20b.md#L3"},
+		{"bitbucket", "v1.0", refTag, "https://host/o/r/docs/a%20b.md?at=v1.0#3"},
+	}
+	for _, test := range tests {
+		repo := &repository{webURL: "https://host/o/r", ref: test.ref, refKind: test.refKind}
+		if got := fileURL(test.kind, repo, "docs/a b.md", 3); got != test.want {
+			t.Errorf("fileURL(%s, %s %s):\nhave: %s\nwant: %s", test.kind, test.refKind, test.ref, got, test.want)
+		}
+	}
+}
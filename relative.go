@@ -0,0 +1,198 @@
+package main
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// treeChecker is implemented by checkers that need all repository
+// file paths, including the files that are not pushed to them,
+// like vendored or excluded ones.
+type treeChecker interface {
+	SetTree(paths []string)
+}
+
+// linkTargetChecker is implemented by checkers that need the files
+// linked from the pushed files. PushTargets is called after the
+// pushed files contents are fetched, with all repository files
+// that can be checked, so it can require the targets contents.
+type linkTargetChecker interface {
+	PushTargets(files map[string]*repoFile)
+}
+
+var (
+	// markdownLinkRE matches "[text](dest)" and "![alt](dest "title")".
+	// Link text can contain nested images, like "[![badge](img)](dest)".
+	markdownLinkRE = regexp.MustCompile(`!?\[(?:[^\[\]]|\[[^\]]*\])*\]\(\s*<?([^)\s>]*)>?(?:\s+(?:"[^"]*"|'[^']*'))?\s*\)`)
+
+	// markdownRefDefRE matches "[id]: dest" link reference definition,
+	// but not "[^1]: text" footnote.
+	markdownRefDefRE = regexp.MustCompile(`(?m)^ {0,3}\[[^\]^][^\]]*\]:[ \t]*<?([^\s>]+)`)
+
+	// htmlLinkRE matches href and src attributes.
+	htmlLinkRE = regexp.MustCompile(`(?i)\b(?:href|src)\s*=\s*["']([^"']*)["']`)
+
+	// rstLinkRE matches "`text <dest>`_" hyperlink.
+	rstLinkRE = regexp.MustCompile("`[^`<]*<([^>`]+)>`__?")
+
+	// rstTargetRE matches ".. _name: dest" target and image, figure
+	// and include directives.
+	rstTargetRE = regexp.MustCompile(`(?m)^[ \t]*\.\.[ \t]+(?:_[^:\n]+:|(?:image|figure|include)::)[ \t]*(\S+)`)
+
+	// urlSchemeRE matches "scheme:" URL prefix, like "https:" or "mailto:".
+	urlSchemeRE = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+// extractRelativeLinks returns links that point inside the repository,
+// including "#anchor" links to the same file.
+func extractRelativeLinks(filename, contents string) []docLink {
+	var patterns []*regexp.Regexp
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".html", ".htm":
+		patterns = []*regexp.Regexp{htmlLinkRE}
+	case ".rst":
+		contents = blankRSTLiterals(contents)
+		patterns = []*regexp.Regexp{rstLinkRE, rstTargetRE}
+	default:
+		contents = blankMarkdownCode(contents)
+		patterns = []*regexp.Regexp{markdownLinkRE, markdownRefDefRE, htmlLinkRE}
+	}
+
+	var links []docLink
+	for _, re := range patterns {
+		for _, m := range re.FindAllStringSubmatchIndex(contents, -1) {
+			dest := contents[m[2]:m[3]]
+			if dest == "" || urlSchemeRE.MatchString(dest) || strings.HasPrefix(dest, "//") {
+				continue
+			}
+			links = append(links, docLink{url: dest, offset: m[2]})
+		}
+	}
+	return links
+}
+
+// resolveRelativeLink returns a repository path and an anchor of the
+// link found in the file. Returns empty path for the same file anchors.
+// ok is false if the link points outside of the repository, like
+// "../../issues" that GitHub resolves to the repository issues page.
+func resolveRelativeLink(file, link string) (target, anchor string, ok bool) {
+	if i := strings.IndexByte(link, '#'); i != -1 {
+		link, anchor = link[:i], link[i+1:]
+	}
+	if i := strings.IndexByte(link, '?'); i != -1 {
+		// Like "logo.png?raw=true".
+		link = link[:i]
+	}
+	if unescaped, err := url.PathUnescape(link); err == nil {
+		link = unescaped
+	}
+	if link == "" {
+		return "", anchor, true
+	}
+
+	if strings.HasPrefix(link, "/") {
+		target = path.Clean(link[1:])
+	} else {
+		target = path.Join(path.Dir(file), link)
+	}
+	if target == ".." || strings.HasPrefix(target, "../") {
+		return "", "", false
+	}
+	return target, anchor, true
+}
+
+var (
+	// markdownHeadingRE matches ATX "## heading" lines.
+	markdownHeadingRE = regexp.MustCompile(`^ {0,3}#{1,6}[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+
+	// setextUnderlineRE matches "===" and "---" lines that make
+	// the previous line a heading.
+	setextUnderlineRE = regexp.MustCompile(`^ {0,3}(?:=+|-+)[ \t]*$`)
+
+	// htmlAnchorRE matches explicit HTML anchors, like <a name="x">.
+	htmlAnchorRE = regexp.MustCompile(`(?i)<[a-z][^>]*\b(?:name|id)\s*=\s*["']([^"']+)["']`)
+
+	// headingLinkRE matches links and images inside a heading.
+	headingLinkRE = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+
+	// headingTagRE matches HTML tags inside a heading.
+	headingTagRE = regexp.MustCompile(`<[^>]*>`)
+)
+
+// markdownAnchors returns anchors that GitHub generates for
+// the Markdown file headings, plus the explicit HTML anchors.
+func markdownAnchors(contents string) map[string]bool {
+	// Code spans are kept, they are a part of the heading text.
+	contents = blankMarkdownFences(contents)
+	anchors := make(map[string]bool)
+	seen := make(map[string]int)
+	addHeading := func(text string) {
+		slug := headingSlug(text)
+		if n := seen[slug]; n != 0 {
+			// Duplicated headings get "-1", "-2" and so on suffixes.
+			anchors[slug+"-"+strconv.Itoa(n)] = true
+		} else {
+			anchors[slug] = true
+		}
+		seen[slug]++
+	}
+
+	lines := strings.Split(contents, "\n")
+	for i, l := range lines {
+		for _, m := range htmlAnchorRE.FindAllStringSubmatch(l, -1) {
+			anchors[m[1]] = true
+		}
+		if m := markdownHeadingRE.FindStringSubmatch(l); m != nil {
+			addHeading(m[1])
+			continue
+		}
+		isText := strings.TrimSpace(l) != "" && !strings.HasPrefix(strings.TrimSpace(l), "-")
+		if isText && i+1 < len(lines) && setextUnderlineRE.MatchString(lines[i+1]) {
+			addHeading(l)
+		}
+	}
+	return anchors
+}
+
+// headingSlug returns a heading anchor, the same way GitHub does:
+// the text is lowercased, punctuation is removed and spaces become hyphens.
+func headingSlug(heading string) string {
+	heading = headingLinkRE.ReplaceAllString(heading, "$1")
+	heading = headingTagRE.ReplaceAllString(heading, "")
+	heading = strings.TrimSpace(heading)
+	var buf strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			buf.WriteByte('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+// hasAnchor reports whether anchor is one of anchors.
+// Like browsers on GitHub, it ignores the anchor case
+// and "user-content-" prefix that GitHub adds to heading ids.
+func hasAnchor(anchors map[string]bool, anchor string) bool {
+	if unescaped, err := url.PathUnescape(anchor); err == nil {
+		anchor = unescaped
+	}
+	anchor = strings.TrimPrefix(anchor, "user-content-")
+	return anchors[anchor] || anchors[strings.ToLower(anchor)]
+}
+
+// isMarkdownFile reports whether anchors of the file are generated from its headings.
+func isMarkdownFile(filename string) bool {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".md", ".markdown", ".mdown", ".mkd":
+		return true
+	default:
+		return false
+	}
+}
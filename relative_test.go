@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRelativeLinkAnchorsInLinkedFiles(t *testing.T) {
+	files := map[string]*repoFile{
+		"README.md": {origName: "README.md", baseName: "README.md",
+			contents: "See [guide](docs/guide.md#getting-started), [bad](docs/guide.md#missing) and [api](docs/api.md).\n"},
+		"docs/guide.md": {origName: "docs/guide.md", baseName: "guide.md"},
+		"docs/api.md":   {origName: "docs/api.md", baseName: "api.md"},
+	}
+	c := &relativeLinkChecker{}
+	c.Reset(&repository{owner: "o", name: "r"})
+	c.SetTree([]string{"README.md", "docs/guide.md", "docs/api.md"})
+	for _, name := range []string{"README.md", "docs/guide.md", "docs/api.md"} {
+		c.PushFile(files[name])
+	}
+	c.PushTargets(files)
+
+	if !files["docs/guide.md"].require.contents {
+		t.Errorf("docs/guide.md contents are not required")
+	}
+	if files["docs/api.md"].require.contents {
+		t.Errorf("docs/api.md contents are required, but it's linked without an anchor")
+	}
+	files["docs/guide.md"].contents = "# Getting `Started`!\n"
+
+	var have []string
+	for _, w := range c.CheckFiles() {
+		have = append(have, w.String())
+	}
+	want := []string{"README.md:1:51: docs/guide.md#missing: no such anchor"}
+	if fmt.Sprint(have) != fmt.Sprint(want) {
+		t.Errorf("warnings:\nhave: %q\nwant: %q", have, want)
+	}
+}
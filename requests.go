@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// requestCounter counts API requests made by all providers.
+// It also tracks the latest rate limit status reported by the API.
+//
+// It's safe to use requestCounter from multiple goroutines.
+type requestCounter struct {
+	mu sync.Mutex
+
+	count int
+
+	// remaining is a number of requests left until the rate limit reset.
+	// -1 if API hasn't reported it yet.
+	remaining int
+
+	// reset is a time when the rate limit is reset.
+	reset time.Time
+}
+
+func newRequestCounter() *requestCounter {
+	return &requestCounter{remaining: -1}
+}
+
+// add records a single request completion.
+// Rate limit headers of the response are used to update the quota.
+// resp can be nil if request failed without a response.
+func (c *requestCounter) add(resp *http.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.count++
+	if resp == nil {
+		return
+	}
+	remaining, reset, ok := parseRateLimit(resp.Header)
+	if ok {
+		c.remaining = remaining
+		c.reset = reset
+	}
+}
+
+// requests returns the number of made requests.
+func (c *requestCounter) requests() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count
+}
+
+// quota returns the remaining requests and the rate limit reset time.
+// Remaining requests count is -1 if it's unknown.
+func (c *requestCounter) quota() (int, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.remaining, c.reset
+}
+
+func (c *requestCounter) String() string {
+	remaining, _ := c.quota()
+	if remaining == -1 {
+		return fmt.Sprintf("made %d requests so far", c.requests())
+	}
+	return fmt.Sprintf("made %d requests so far, %d remaining", c.requests(), remaining)
+}
+
+// parseRateLimit extracts rate limit info from response headers.
+//
+// GitHub and Gitea use "X-RateLimit-" headers prefix,
+// GitLab uses "RateLimit-". Reset time is a Unix timestamp.
+func parseRateLimit(h http.Header) (remaining int, reset time.Time, ok bool) {
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		remainingHeader := h.Get(prefix + "Remaining")
+		resetHeader := h.Get(prefix + "Reset")
+		if remainingHeader == "" || resetHeader == "" {
+			continue
+		}
+		n, err := strconv.Atoi(remainingHeader)
+		if err != nil {
+			continue
+		}
+		unix, err := strconv.ParseInt(resetHeader, 10, 64)
+		if err != nil {
+			continue
+		}
+		return n, time.Unix(unix, 0), true
+	}
+	return 0, time.Time{}, false
+}
+
+// rateLimitTransport is an http.RoundTripper that counts requests,
+// waits for the rate limit reset when the quota is exhausted and
+// retries transient failures with exponential backoff.
+type rateLimitTransport struct {
+	base     http.RoundTripper
+	requests *requestCounter
+
+	// maxRetries is a max number of retries per request.
+	maxRetries int
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := t.waitQuota(req); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		t.requests.add(resp)
+
+		delay, reason := t.retryDelay(req, resp, err)
+		if reason == "" || attempt == t.maxRetries {
+			return resp, err
+		}
+		if delay == 0 {
+			delay = time.Second << uint(attempt)
+		}
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		log.Printf("\tretry %s %s in %s (%s)",
+			req.Method, req.URL.Path, delay.Round(time.Second), reason)
+		if err := sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitQuota blocks until the rate limit reset if no requests are remaining.
+func (t *rateLimitTransport) waitQuota(req *http.Request) error {
+	remaining, reset := t.requests.quota()
+	if remaining != 0 {
+		return nil
+	}
+	delay := time.Until(reset)
+	if delay <= 0 {
+		return nil
+	}
+	// Add a small margin to avoid clock skew issues.
+	delay += time.Second
+	log.Printf("\trate limit exceeded, waiting %s until reset", delay.Round(time.Second))
+	return sleepContext(req.Context(), delay)
+}
+
+// retryDelay returns a non-empty reason if request should be retried.
+// If returned delay is 0, exponential backoff is used.
+func (t *rateLimitTransport) retryDelay(req *http.Request, resp *http.Response, err error) (time.Duration, string) {
+	if req.Body != nil && req.GetBody == nil {
+		// Can't send the same body twice.
+		return 0, ""
+	}
+	if err != nil {
+		if req.Context().Err() != nil {
+			return 0, ""
+		}
+		if !isIdempotent(req) && !isDialError(err) {
+			// The request could be already processed by the server.
+			return 0, ""
+		}
+		return 0, err.Error()
+	}
+
+	switch {
+	case resp.StatusCode >= 500:
+		if !isIdempotent(req) {
+			// A pull request creation, for example, can fail
+			// with 502 after the pull request was already created.
+			return 0, ""
+		}
+		return 0, resp.Status
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		// Abuse detection mechanism responses have Retry-After header.
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return time.Duration(secs) * time.Second, "abuse detection"
+		}
+		remaining, reset, ok := parseRateLimit(resp.Header)
+		if ok && remaining == 0 {
+			// Quota is exhausted, waitQuota will wait for the reset.
+			return time.Until(reset) + time.Second, "rate limit exceeded"
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return 0, resp.Status
+		}
+	}
+	return 0, ""
+}
+
+// isIdempotent reports whether req can be safely sent twice.
+// Non-idempotent requests are only retried when they're rejected
+// before being processed, like when the rate limit is exceeded.
+func isIdempotent(req *http.Request) bool {
+	return req.Method == "" || req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
+// isDialError reports whether err happened before the connection
+// was established, so the request never reached the server.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// sleepContext is like time.Sleep, but can be interrupted by ctx cancellation.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
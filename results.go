@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// resultEntry is a single warning from the previously saved lint results.
+type resultEntry struct {
+	// repo is a repository location, like "github.com/owner/name".
+	repo    string
+	checker string
+
+	// text is a warning text without the checker name,
+	// as it's printed by the text output format.
+	text string
+
+	// language is a repository major language.
+	// Only json and jsonl results have it.
+	language string
+}
+
+// results is a previously saved lint results file contents.
+type results struct {
+	entries []resultEntry
+
+	// repos is a number of checked repositories, including the
+	// ones without warnings. It's 0 if the results file doesn't
+	// have progress lines or summary records.
+	repos int
+
+	// checked is a set of checked repositories full names,
+	// like "owner/name". It's empty for the results that
+	// don't record them, like older json results.
+	checked map[string]bool
+}
+
+// checkingRE matches "checking owner/name (...) ..." progress line.
+var checkingRE = regexp.MustCompile(`^\tchecking (\S+) `)
+
+// legacyUnwantedFileRE matches "remove <kind> file: <path>" warning text.
+var legacyUnwantedFileRE = regexp.MustCompile(`^(remove .* file): (.*)$`)
+
+// legacyFileWarnings are warnings that older versions printed
+// without a file name. text matches such warning text and
+// file is the file name that is implied.
+var legacyFileWarnings = map[string]struct {
+	text *regexp.Regexp
+	file string
+}{
+	"travis lint":  {regexp.MustCompile("^use `"), ".travis.yml"},
+	"readme badge": {regexp.MustCompile(`^could add `), "README.md"},
+
+	// Any root README could be checked, but only Markdown
+	// files usually have code blocks, so README.md is assumed.
+	"code snippet": {regexp.MustCompile(`^block #\d+: `), "README.md"},
+}
+
+// positionRE matches "file:line:col: " warning text prefix.
+var positionRE = regexp.MustCompile(`^([^:]*)(?::\d+){1,2}: `)
+
+// fingerprint returns a warning identity that doesn't depend on
+// its line and column, so unrelated file edits don't change it.
+func (e *resultEntry) fingerprint() string {
+	text := positionRE.ReplaceAllString(e.text, "$1: ")
+	return e.repo + "\x00" + e.checker + "\x00" + text
+}
+
+// readResults reads lint results saved in text, json or jsonl output format.
+// Text format progress lines, like "checking ...", are only used
+// to collect the checked repositories.
+func readResults(filename string) (*results, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		var records []*jsonRecord
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		return recordsResults(records), nil
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var records []*jsonRecord
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		for dec.More() {
+			var rec jsonRecord
+			if err := dec.Decode(&rec); err != nil {
+				return nil, fmt.Errorf("%s: %v", filename, err)
+			}
+			records = append(records, &rec)
+		}
+		return recordsResults(records), nil
+	default:
+		return textResults(data), nil
+	}
+}
+
+func recordsResults(records []*jsonRecord) *results {
+	res := &results{checked: make(map[string]bool)}
+	for _, rec := range records {
+		switch rec.Type {
+		case "summary":
+			// Resumed runs append their own summary.
+			if rec.Repos != nil {
+				res.repos += *rec.Repos
+			}
+			for _, name := range rec.Checked {
+				res.checked[name] = true
+			}
+		case "warning":
+			w := warning{file: rec.File, line: rec.Line, col: rec.Column, message: rec.Message}
+			res.entries = append(res.entries, resultEntry{
+				repo:     recordRepoPath(rec),
+				checker:  rec.Checker,
+				text:     w.String(),
+				language: rec.Language,
+			})
+		}
+	}
+	return res
+}
+
+// recordRepoPath returns a repository location in the same
+// form as it's printed by the text output format.
+func recordRepoPath(rec *jsonRecord) string {
+	u, err := url.Parse(rec.URL)
+	if err != nil || u.Host == "" {
+		return rec.Repo
+	}
+	return u.Host + "/" + rec.Repo
+}
+
+func textResults(data []byte) *results {
+	// Resumed runs can check the same repository again.
+	checked := make(map[string]bool)
+	res := &results{checked: checked}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := checkingRE.FindStringSubmatch(line); m != nil {
+			checked[m[1]] = true
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		parts := strings.SplitN(line, ": ", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if _, ok := checkerDocs[parts[1]]; !ok {
+			// Not a warning line, like an error message
+			// that was printed to the same file.
+			continue
+		}
+		e := resultEntry{
+			repo:    parts[0],
+			checker: parts[1],
+			text:    parts[2],
+		}
+		if e.checker == "unwanted file" {
+			// Older versions printed the file name after the message.
+			e.text = legacyUnwantedFileRE.ReplaceAllString(e.text, "$2: $1")
+		}
+		if legacy, ok := legacyFileWarnings[e.checker]; ok && legacy.text.MatchString(e.text) {
+			e.text = legacy.file + ": " + e.text
+		}
+		res.entries = append(res.entries, e)
+	}
+	res.repos = len(checked)
+	return res
+}
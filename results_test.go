@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestTextResultsLegacy(t *testing.T) {
+	data := []byte(`	checking o/r (1/1, made 1 requests so far) ...
+github.com/o/r: unwanted file: remove Vim swap file: .a.swp
+github.com/o/r: code snippet: block #3: add "go" language marker
+github.com/o/r: code snippet: block #4: use "go" marker instead of "golang"
+github.com/o/r: travis lint: use ` + "`go vet` instead of `go tool vet`" + `
+github.com/o/r: readme badge: could add travis-ci build status badge https://travis-ci.org/o/r.svg
+github.com/o/r: misspell: README.md:1:0: "existance" is a misspelling of "existence"
+`)
+	current := []warning{
+		{checker: "unwanted file", file: ".a.swp", message: "remove Vim swap file"},
+		{checker: "code snippet", file: "README.md", message: `block #3: add "go" language marker`},
+		{checker: "code snippet", file: "README.md", line: 10, col: 4, message: `block #4: use "go" marker instead of "golang"`},
+		{checker: "travis lint", file: ".travis.yml", line: 5, col: 5, message: "use `go vet` instead of `go tool vet`"},
+		{checker: "readme badge", file: "README.md", message: "could add travis-ci build status badge https://travis-ci.org/o/r.svg"},
+		{checker: "misspell", file: "README.md", line: 1, col: 3, message: `"existance" is a misspelling of "existence"`},
+	}
+
+	res := textResults(data)
+	if res.repos != 1 {
+		t.Errorf("got %d repos, want 1", res.repos)
+	}
+	if len(res.entries) != len(current) {
+		t.Fatalf("got %d entries, want %d", len(res.entries), len(current))
+	}
+	for i, e := range res.entries {
+		w := current[i]
+		want := resultEntry{repo: "github.com/o/r", checker: w.checker, text: w.String()}
+		if e.fingerprint() != want.fingerprint() {
+			t.Errorf("%s entry doesn't match the current warning:\nhave: %s\nwant: %s", e.checker, e.text, want.text)
+		}
+	}
+}
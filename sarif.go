@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+// SARIF 2.1.0 log subset that is used by repolint.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool                     sarifTool             `json:"tool"`
+	VersionControlProvenance []sarifVersionControl `json:"versionControlProvenance,omitempty"`
+	Results                  []*sarifResult        `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifVersionControl struct {
+	RepositoryURI string `json:"repositoryUri"`
+	Branch        string `json:"branch,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string           `json:"ruleId"`
+	RuleIndex int              `json:"ruleIndex"`
+	Level     string           `json:"level"`
+	Message   sarifMessage     `json:"message"`
+	Locations []*sarifLocation `json:"locations,omitempty"`
+	Fixes     []*sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage           `json:"description"`
+	ArtifactChanges []*sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []*sarifReplacement   `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+// sarifReporter prints a SARIF log with a run per repository.
+// Every enabled checker is mapped to a rule.
+type sarifReporter struct {
+	w   io.Writer
+	l   *linter
+	log sarifLog
+}
+
+func (r *sarifReporter) report(repo *repository, warnings []warning) error {
+	run := &sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "repolint",
+				InformationURI: "https://github.com/quasilyte/repolint",
+			},
+		},
+		Results: []*sarifResult{},
+	}
+	counts := make(map[string]int)
+	for _, w := range warnings {
+		counts[w.checker]++
+	}
+	ruleIndex := make(map[string]int)
+	for _, name := range r.l.reportedCheckers(counts) {
+		ruleIndex[name] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, &sarifRule{
+			ID:               name,
+			ShortDescription: sarifMessage{Text: checkerDocs[name]},
+		})
+	}
+	if repo.webURL != "" {
+		run.VersionControlProvenance = []sarifVersionControl{
+			{RepositoryURI: repo.webURL, Branch: repo.ref},
+		}
+	}
+
+	for _, w := range warnings {
+		run.Results = append(run.Results, &sarifResult{
+			RuleID:    w.checker,
+			RuleIndex: ruleIndex[w.checker],
+			Level:     sarifLevel(w.severity),
+			Message:   sarifMessage{Text: w.message},
+			Locations: sarifLocations(&w),
+			Fixes:     sarifFixes(&w),
+		})
+	}
+	r.log.Runs = append(r.log.Runs, run)
+	return nil
+}
+
+func (r *sarifReporter) finish(s *runSummary) error {
+	r.log.Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+	r.log.Version = "2.1.0"
+	if r.log.Runs == nil {
+		r.log.Runs = []*sarifRun{}
+	}
+	data, err := json.MarshalIndent(&r.log, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(r.w, "%s\n", data)
+	return err
+}
+
+func sarifLevel(s severity) string {
+	switch s {
+	case severityInfo:
+		return "note"
+	case severityError:
+		return "error"
+	default:
+		return "warning"
+	}
+}
+
+func sarifLocations(w *warning) []*sarifLocation {
+	if w.file == "" {
+		return nil
+	}
+	loc := &sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: pathEscape(w.file)},
+		},
+	}
+	if w.line != 0 {
+		loc.PhysicalLocation.Region = &sarifRegion{
+			StartLine:   w.line,
+			StartColumn: utf16Column(w.lineText, w.col),
+		}
+	}
+	return []*sarifLocation{loc}
+}
+
+// sarifFixes returns a warning suggestion as a fix.
+// Suggestions without a known position can't be expressed as a fix.
+func sarifFixes(w *warning) []*sarifFix {
+	if w.suggestion == "" || w.line == 0 || w.col == 0 {
+		return nil
+	}
+	return []*sarifFix{{
+		Description: sarifMessage{Text: fmt.Sprintf("replace %s with %s", w.original, w.suggestion)},
+		ArtifactChanges: []*sarifArtifactChange{{
+			ArtifactLocation: sarifArtifactLocation{URI: pathEscape(w.file)},
+			Replacements: []*sarifReplacement{{
+				DeletedRegion: sarifRegion{
+					StartLine:   w.line,
+					StartColumn: utf16Column(w.lineText, w.col),
+					EndColumn:   utf16Column(w.lineText, w.col+len(w.original)),
+				},
+				InsertedContent: sarifMessage{Text: w.suggestion},
+			}},
+		}},
+	}}
+}
+
+// utf16Column converts a 1-based byte column to UTF-16 code units,
+// the default SARIF column kind. If the line text is unknown,
+// the line is assumed to be ASCII-only.
+func utf16Column(lineText string, col int) int {
+	if col <= 1 || col-1 > len(lineText) {
+		return col
+	}
+	return len(utf16.Encode([]rune(lineText[:col-1]))) + 1
+}
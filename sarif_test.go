@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestUTF16Column(t *testing.T) {
+	tests := []struct {
+		line string
+		col  int
+		want int
+	}{
+		{"sql is here", 1, 1},
+		{"see sql", 5, 5},
+		{"Привет sql", 14, 8},
+		{"🙂 sql", 6, 4},
+		{"", 7, 7},
+		{"short", 20, 20},
+	}
+	for _, test := range tests {
+		if got := utf16Column(test.line, test.col); got != test.want {
+			t.Errorf("utf16Column(%q, %d) = %d, want %d", test.line, test.col, got, test.want)
+		}
+	}
+}
+
+func TestSarifRepoEnabledChecker(t *testing.T) {
+	l := &linter{checkers: map[string]fileChecker{"misspell": nil, "unwanted file": nil}}
+	r := &sarifReporter{l: l}
+	// Acronym checker is only enabled by the repository config.
+	warnings := []warning{
+		{checker: "unwanted file", file: ".a.swp", message: "remove Vim swap file"},
+		{checker: "acronym", file: "README.md", line: 1, col: 1, message: "sql is an acronym"},
+	}
+	if err := r.report(&repository{owner: "o", name: "r"}, warnings); err != nil {
+		t.Fatal(err)
+	}
+	run := r.log.Runs[0]
+	for _, res := range run.Results {
+		if rule := run.Tool.Driver.Rules[res.RuleIndex]; rule.ID != res.RuleID {
+			t.Errorf("%s result points to %s rule", res.RuleID, rule.ID)
+		}
+	}
+}
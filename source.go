@@ -0,0 +1,277 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// repoSource provides access to the repository files.
+//
+// Checkers don't depend on the source that is being used,
+// they only see the resolved repoFile objects.
+type repoSource interface {
+	// getTree returns all repository file entries.
+	getTree(repo *repository) ([]treeEntry, error)
+
+	// getBlob returns repository file contents.
+	getBlob(repo *repository, entry treeEntry) (string, error)
+}
+
+// treeEntry is a repository tree file entry.
+type treeEntry struct {
+	// path is slash-separated and relative to the repository root.
+	path string
+
+	// sha is a git blob hash.
+	// Empty if the source doesn't report it.
+	sha string
+
+	// mode is a git file mode, like "100644" or "100755".
+	// Empty if the source doesn't report it.
+	mode string
+}
+
+// localSource is implemented by sources that keep repository
+// files on a local filesystem, so no temporary copies are needed.
+type localSource interface {
+	// localPath returns a local filesystem path for the repository file.
+	localPath(path string) string
+}
+
+// dirSource reads repository files from a local working tree.
+type dirSource struct {
+	root string
+}
+
+func (s *dirSource) getTree(repo *repository) ([]treeEntry, error) {
+	var entries []treeEntry
+	err := filepath.Walk(s.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, treeEntry{path: filepath.ToSlash(rel)})
+		return nil
+	})
+	return entries, err
+}
+
+func (s *dirSource) getBlob(repo *repository, entry treeEntry) (string, error) {
+	data, err := ioutil.ReadFile(s.localPath(entry.path))
+	return string(data), err
+}
+
+func (s *dirSource) localPath(path string) string {
+	return filepath.Join(s.root, filepath.FromSlash(path))
+}
+
+// gitSource reads repository files from git objects.
+//
+// Repositories are shallow-cloned on demand, so the whole tree and
+// all blobs are fetched without any API requests. It can also serve
+// a single local bare repository or a git bundle.
+type gitSource struct {
+	tempDir string
+
+	// auth is an HTTP Authorization header value.
+	// If empty, no authorization is performed.
+	auth string
+
+	// gitDirs maps repository name to its git directory path.
+	gitDirs   map[string]string
+	gitDirsMu sync.Mutex
+
+	// localDir is a git directory of the local repository.
+	// If not empty, it's used for every repository.
+	localDir string
+}
+
+func newGitSource(tempDir, auth string) *gitSource {
+	return &gitSource{
+		tempDir: tempDir,
+		auth:    auth,
+		gitDirs: make(map[string]string),
+	}
+}
+
+// openLocal makes git source use a local repository.
+// path can point to a bare repository, a .git directory or a git bundle file.
+func (s *gitSource) openLocal(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		s.localDir = path
+		return nil
+	}
+	// Bundles can't be read directly, so they're cloned first.
+	dst := filepath.Join(s.tempDir, "bundle.git")
+	if _, err := s.git("clone", "--quiet", "--bare", path, dst); err != nil {
+		return err
+	}
+	s.localDir = dst
+	return nil
+}
+
+func (s *gitSource) gitDir(repo *repository) (string, error) {
+	if s.localDir != "" {
+		return s.localDir, nil
+	}
+	key := repo.fullName()
+	s.gitDirsMu.Lock()
+	dir, ok := s.gitDirs[key]
+	s.gitDirsMu.Unlock()
+	if ok {
+		return dir, nil
+	}
+	if repo.cloneURL == "" {
+		return "", errors.New("clone URL is unknown")
+	}
+	if err := checkFetchRef(repo.ref); err != nil {
+		return "", err
+	}
+	// Fetch is used instead of clone, because it can
+	// handle any ref, including commit hashes.
+	dst := filepath.Join(s.tempDir, "clones", key+".git")
+	ref := repo.ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	commands := [][]string{
+		{"init", "--quiet", "--bare", dst},
+		{"--git-dir=" + dst, "remote", "add", "origin", repo.cloneURL},
+		{"--git-dir=" + dst, "fetch", "--quiet", "--depth=1", "--filter=blob:none", "origin", ref},
+	}
+	for _, args := range commands {
+		if _, err := s.git(args...); err != nil {
+			return "", err
+		}
+	}
+	s.gitDirsMu.Lock()
+	s.gitDirs[key] = dst
+	s.gitDirsMu.Unlock()
+	return dst, nil
+}
+
+// checkFetchRef returns an error if ref can't be fetched from a remote.
+// Servers only allow fetching commits by their full hashes.
+func checkFetchRef(ref string) error {
+	if commitSHARE.MatchString(ref) && len(ref) != 40 {
+		return fmt.Errorf("can't fetch abbreviated commit %s, use a full 40-character hash", ref)
+	}
+	return nil
+}
+
+// rev returns a git revision that should be used for the repo objects.
+func (s *gitSource) rev(repo *repository) string {
+	switch {
+	case s.localDir == "":
+		// Cloned repositories contain only the fetched commit.
+		return "FETCH_HEAD"
+	case repo.ref != "":
+		return repo.ref
+	default:
+		return "HEAD"
+	}
+}
+
+// release removes a cloned repository copy.
+func (s *gitSource) release(repo *repository) {
+	key := repo.fullName()
+	s.gitDirsMu.Lock()
+	dir, ok := s.gitDirs[key]
+	delete(s.gitDirs, key)
+	s.gitDirsMu.Unlock()
+	if !ok {
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		log.Printf("\terror: remove %s clone: %v", key, err)
+	}
+}
+
+func (s *gitSource) getTree(repo *repository) ([]treeEntry, error) {
+	dir, err := s.gitDir(repo)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.git("--git-dir="+dir, "ls-tree", "-r", "-z", "--full-tree", s.rev(repo))
+	if err != nil {
+		return nil, err
+	}
+	var entries []treeEntry
+	for _, line := range strings.Split(string(out), "\x00") {
+		// Line format is "<mode> <type> <object>\t<path>".
+		tab := strings.IndexByte(line, '\t')
+		if tab == -1 {
+			continue
+		}
+		fields := strings.Fields(line[:tab])
+		if len(fields) != 3 || fields[1] != "blob" {
+			// Skip submodules.
+			continue
+		}
+		entries = append(entries, treeEntry{path: line[tab+1:], sha: fields[2], mode: fields[0]})
+	}
+	return entries, nil
+}
+
+func (s *gitSource) getBlob(repo *repository, entry treeEntry) (string, error) {
+	dir, err := s.gitDir(repo)
+	if err != nil {
+		return "", err
+	}
+	object := entry.sha
+	if object == "" {
+		object = s.rev(repo) + ":" + entry.path
+	}
+	out, err := s.git("--git-dir="+dir, "cat-file", "blob", object)
+	return string(out), err
+}
+
+// git runs git command with given args and returns its stdout.
+func (s *gitSource) git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if s.auth != "" {
+		// Pass the credentials through the environment so they
+		// don't appear in the process list.
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: "+s.auth)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		command := args[0]
+		if strings.HasPrefix(command, "--git-dir=") {
+			command = args[1]
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git %s: %v: %s",
+				command, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git %s: %v", command, err)
+	}
+	return out, nil
+}
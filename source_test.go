@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files with the specified contents under root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// treePaths returns the tree entries paths.
+func treePaths(entries []treeEntry) []string {
+	paths := make([]string, len(entries))
+	for i, entry := range entries {
+		paths[i] = entry.path
+	}
+	return paths
+}
+
+func TestDirSourceGetTree(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"README.md":        "# repo\n",
+		"docs/guide.md":    "# guide\n",
+		".git/config":      "[core]\n",
+		".git/refs/HEAD":   "ref: refs/heads/master\n",
+		"sub/.git/HEAD":    "ref: refs/heads/master\n",
+		".github/ci.yml":   "on: push\n",
+		"src/main.go":      "package main\n",
+		"src/main_test.go": "package main\n",
+	})
+	if err := os.Symlink("README.md", filepath.Join(root, "link.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &dirSource{root: root}
+	repo := &repository{name: "repo"}
+	entries, err := s.getTree(repo)
+	if err != nil {
+		t.Fatalf("getTree: %v", err)
+	}
+	paths := treePaths(entries)
+	sort.Strings(paths)
+	// .git directories and symlinks are skipped.
+	want := []string{".github/ci.yml", "README.md", "docs/guide.md", "src/main.go", "src/main_test.go"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("getTree:\nhave: %q\nwant: %q", paths, want)
+	}
+
+	contents, err := s.getBlob(repo, treeEntry{path: "docs/guide.md"})
+	if err != nil || contents != "# guide\n" {
+		t.Errorf("getBlob: got %q, %v", contents, err)
+	}
+	if got := s.localPath("docs/guide.md"); got != filepath.Join(root, "docs", "guide.md") {
+		t.Errorf("localPath: got %q", got)
+	}
+}
+
+// newTestGitRepo creates a repository with a commit per files map,
+// tagged as "v1", "v2" and so on, and returns its bare copy and bundle paths.
+// The test is skipped if git is not installed.
+func newTestGitRepo(t *testing.T, commits ...map[string]string) (bareDir, bundle string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+	if err := os.MkdirAll(work, 0755); err != nil {
+		t.Fatal(err)
+	}
+	run(work, "init", "--quiet")
+	for i, files := range commits {
+		writeFiles(t, work, files)
+		run(work, "add", ".")
+		run(work, "commit", "--quiet", "-m", "commit")
+		run(work, "tag", fmt.Sprintf("v%d", i+1))
+	}
+	bareDir = filepath.Join(root, "repo.git")
+	bundle = filepath.Join(root, "repo.bundle")
+	run(root, "clone", "--quiet", "--bare", work, bareDir)
+	run(work, "bundle", "create", bundle, "HEAD", "--all")
+	return bareDir, bundle
+}
+
+func TestGitSourceLocal(t *testing.T) {
+	bareDir, bundle := newTestGitRepo(t, map[string]string{
+		"README.md":     "# repo\n",
+		"docs/guide.md": "# guide\n",
+	})
+
+	repo := &repository{name: "repo"}
+	for _, path := range []string{bareDir, bundle} {
+		s := newGitSource(t.TempDir(), "")
+		if err := s.openLocal(path); err != nil {
+			t.Fatalf("openLocal(%s): %v", path, err)
+		}
+		entries, err := s.getTree(repo)
+		if err != nil {
+			t.Fatalf("%s: getTree: %v", path, err)
+		}
+		want := []string{"README.md", "docs/guide.md"}
+		if paths := treePaths(entries); !reflect.DeepEqual(paths, want) {
+			t.Errorf("%s: getTree: got %q, want %q", path, paths, want)
+		}
+		// Blobs are read both by their hashes and by paths.
+		for _, entry := range []treeEntry{entries[1], {path: "docs/guide.md"}} {
+			contents, err := s.getBlob(repo, entry)
+			if err != nil || contents != "# guide\n" {
+				t.Errorf("%s: getBlob(%+v): got %q, %v", path, entry, contents, err)
+			}
+		}
+	}
+
+	s := newGitSource(t.TempDir(), "")
+	if err := s.openLocal(filepath.Join(t.TempDir(), "missing.bundle")); err == nil {
+		t.Errorf("openLocal: no error for a missing path")
+	}
+}
+
+func TestGitSourceClone(t *testing.T) {
+	bareDir, _ := newTestGitRepo(t, map[string]string{"README.md": "# repo\n"})
+
+	s := newGitSource(t.TempDir(), "")
+	repo := &repository{owner: "o", name: "repo", cloneURL: "file://" + bareDir}
+	contents, err := s.getBlob(repo, treeEntry{path: "README.md"})
+	if err != nil || contents != "# repo\n" {
+		t.Fatalf("getBlob: got %q, %v", contents, err)
+	}
+	dir := s.gitDirs["o/repo"]
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("no clone: %v", err)
+	}
+	s.release(repo)
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("release: clone is not removed")
+	}
+
+	missing := &repository{owner: "o", name: "missing", cloneURL: "file://" + filepath.Dir(bareDir) + "/missing.git"}
+	if _, err := s.getTree(missing); err == nil {
+		t.Errorf("getTree: no error for a missing repository")
+	}
+	if _, err := s.getTree(&repository{owner: "o", name: "r"}); err == nil {
+		t.Errorf("getTree: no error for an unknown clone URL")
+	}
+}
+
+func TestGitSourceRef(t *testing.T) {
+	bareDir, _ := newTestGitRepo(t,
+		map[string]string{"README.md": "# v1\n"},
+		map[string]string{"README.md": "# v2\n"})
+
+	local := newGitSource(t.TempDir(), "")
+	if err := local.openLocal(bareDir); err != nil {
+		t.Fatal(err)
+	}
+	clone := newGitSource(t.TempDir(), "")
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"", "# v2\n"},
+		{"v1", "# v1\n"},
+		{"v2", "# v2\n"},
+	}
+	for _, test := range tests {
+		for _, s := range []*gitSource{local, clone} {
+			repo := &repository{owner: "o", name: "repo", cloneURL: "file://" + bareDir, ref: test.ref}
+			contents, err := s.getBlob(repo, treeEntry{path: "README.md"})
+			if err != nil || contents != test.want {
+				t.Errorf("ref %q (local=%v): got %q, %v, want %q", test.ref, s == local, contents, err, test.want)
+			}
+			s.release(repo)
+		}
+	}
+}
+
+func TestGitSourceCommitRef(t *testing.T) {
+	bareDir, _ := newTestGitRepo(t,
+		map[string]string{"README.md": "# v1\n"},
+		map[string]string{"README.md": "# v2\n"})
+	out, err := exec.Command("git", "--git-dir="+bareDir, "rev-parse", "v1").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha := strings.TrimSpace(string(out))
+
+	local := newGitSource(t.TempDir(), "")
+	if err := local.openLocal(bareDir); err != nil {
+		t.Fatal(err)
+	}
+	clone := newGitSource(t.TempDir(), "")
+	for _, ref := range []string{sha, sha[:7]} {
+		for _, s := range []*gitSource{local, clone} {
+			repo := &repository{owner: "o", name: "repo", cloneURL: "file://" + bareDir, ref: ref}
+			contents, err := s.getBlob(repo, treeEntry{path: "README.md"})
+			s.release(repo)
+			if s == clone && len(ref) != 40 {
+				// Abbreviated hashes can't be fetched.
+				if err == nil || !strings.Contains(err.Error(), "full 40-character hash") {
+					t.Errorf("ref %s (clone): got %v error, want abbreviated commit error", ref, err)
+				}
+				continue
+			}
+			if err != nil || contents != "# v1\n" {
+				t.Errorf("ref %s (local=%v): got %q, %v, want %q", ref, s == local, contents, err, "# v1\n")
+			}
+		}
+	}
+}
+
+// brokenBlobSource is a repoSource that fails to fetch some files.
+type brokenBlobSource map[string]string
+
+func (s brokenBlobSource) getTree(repo *repository) ([]treeEntry, error) {
+	var entries []treeEntry
+	for path := range s {
+		entries = append(entries, treeEntry{path: path})
+	}
+	return entries, nil
+}
+
+func (s brokenBlobSource) getBlob(repo *repository, entry treeEntry) (string, error) {
+	if s[entry.path] == "" {
+		return "", errors.New("503 Service Unavailable")
+	}
+	return s[entry.path], nil
+}
+
+func TestResolveFilesError(t *testing.T) {
+	l := &linter{
+		tempDir:    t.TempDir(),
+		source:     brokenBlobSource{"README.md": "# repo\n", "docs/guide.md": ""},
+		fetchLimit: make(chan struct{}, 2),
+	}
+	repo := &repository{owner: "o", name: "r"}
+	if err := os.MkdirAll(l.repoTempDir(repo), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := []*repoFile{{origName: "README.md"}, {origName: "docs/guide.md"}}
+	for _, f := range files {
+		f.require.contents = true
+	}
+
+	err := l.resolveFiles(repo, files)
+	if err == nil || !strings.Contains(err.Error(), "get r/docs/guide.md contents") {
+		t.Fatalf("resolveFiles: got %v error, want docs/guide.md fetch error", err)
+	}
+	if files[0].contents != "# repo\n" {
+		t.Errorf("README.md: got %q contents", files[0].contents)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// statsRow is a warnings count for a single checker, organization or language.
+type statsRow struct {
+	Name string `json:"name"`
+
+	// Repos is a number of repositories with warnings.
+	Repos    int `json:"repos"`
+	Warnings int `json:"warnings"`
+}
+
+// resultsStats is a lint results totals.
+type resultsStats struct {
+	// Repos is a number of checked repositories.
+	Repos    int `json:"repos"`
+	Warnings int `json:"warnings"`
+
+	Checkers  []statsRow `json:"checkers"`
+	Orgs      []statsRow `json:"orgs"`
+	Languages []statsRow `json:"languages"`
+}
+
+// runStats implements "stats" command that prints lint results totals.
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: repolint stats [flags] results...\n")
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "markdown",
+		`output format: markdown, csv or json`)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("expected results files or directories")
+	}
+	filenames, err := statsFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+	var collector statsCollector
+	for _, filename := range filenames {
+		res, err := readResults(filename)
+		if err != nil {
+			return err
+		}
+		collector.add(filename, res)
+	}
+
+	stats := collector.stats()
+	switch *format {
+	case "markdown":
+		return printStatsMarkdown(os.Stdout, stats)
+	case "csv":
+		return printStatsCSV(os.Stdout, stats)
+	case "json":
+		return printStatsJSON(os.Stdout, stats)
+	default:
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+}
+
+// statsFiles expands directories, like "issues/", to the files they contain.
+func statsFiles(paths []string) ([]string, error) {
+	var filenames []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			filenames = append(filenames, path)
+			continue
+		}
+		files, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.Mode().IsRegular() {
+				filenames = append(filenames, filepath.Join(path, f.Name()))
+			}
+		}
+	}
+	return filenames, nil
+}
+
+// statsCollector accumulates totals of several results files.
+type statsCollector struct {
+	repos    int
+	warnings int
+
+	checkers  statsGroup
+	orgs      statsGroup
+	languages statsGroup
+}
+
+func (c *statsCollector) add(filename string, res *results) {
+	repos := make(map[string]bool)
+	for _, e := range res.entries {
+		repos[e.repo] = true
+		c.warnings++
+		c.checkers.add(e.checker, e.repo)
+		c.orgs.add(statsOrg(filename, e.repo), e.repo)
+		if e.language != "" {
+			c.languages.add(e.language, e.repo)
+		}
+	}
+	if res.repos != 0 {
+		c.repos += res.repos
+	} else {
+		// Repositories without warnings are unknown.
+		c.repos += len(repos)
+	}
+}
+
+func (c *statsCollector) stats() *resultsStats {
+	return &resultsStats{
+		Repos:     c.repos,
+		Warnings:  c.warnings,
+		Checkers:  c.checkers.rows(),
+		Orgs:      c.orgs.rows(),
+		Languages: c.languages.rows(),
+	}
+}
+
+// statsOrg returns a repository owner.
+//
+// Older text results have no owner in the repository location,
+// a results file name is used instead, like "google" for "issues/google.txt".
+func statsOrg(filename, repo string) string {
+	parts := strings.Split(repo, "/")
+	if len(parts) >= 2 {
+		return parts[len(parts)-2]
+	}
+	base := filepath.Base(filename)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// statsGroup counts warnings and repositories by some key.
+type statsGroup struct {
+	warnings map[string]int
+	repos    map[string]map[string]bool
+}
+
+func (g *statsGroup) add(key, repo string) {
+	if g.warnings == nil {
+		g.warnings = make(map[string]int)
+		g.repos = make(map[string]map[string]bool)
+	}
+	g.warnings[key]++
+	if g.repos[key] == nil {
+		g.repos[key] = make(map[string]bool)
+	}
+	g.repos[key][repo] = true
+}
+
+// rows returns the group counts, most warnings first.
+func (g *statsGroup) rows() []statsRow {
+	rows := make([]statsRow, 0, len(g.warnings))
+	for key, n := range g.warnings {
+		rows = append(rows, statsRow{Name: key, Repos: len(g.repos[key]), Warnings: n})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Warnings != rows[j].Warnings {
+			return rows[i].Warnings > rows[j].Warnings
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+// capitalize returns s with its first letter in upper case.
+// Results can have an empty checker name, like JSON records without it.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// printStatsMarkdown prints tables in the same format as README uses.
+func printStatsMarkdown(w io.Writer, stats *resultsStats) error {
+	fmt.Fprintf(w, "| Kind of an issue | Numbers reported |\n")
+	fmt.Fprintf(w, "| --- | --- |\n")
+	for _, row := range stats.Checkers {
+		fmt.Fprintf(w, "| %s | %d |\n", capitalize(row.Name), row.Warnings)
+	}
+	fmt.Fprintf(w, "\nNumber of checked repositories: %d.\n", stats.Repos)
+
+	printTable := func(title string, rows []statsRow) {
+		if len(rows) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n| %s | Repositories with issues | Numbers reported |\n", title)
+		fmt.Fprintf(w, "| --- | --- | --- |\n")
+		for _, row := range rows {
+			fmt.Fprintf(w, "| %s | %d | %d |\n", markdownEscape(row.Name), row.Repos, row.Warnings)
+		}
+	}
+	printTable("Organization", stats.Orgs)
+	printTable("Language", stats.Languages)
+	return nil
+}
+
+// printStatsCSV prints all rows as a single table.
+// A "total" row has a number of checked repositories.
+func printStatsCSV(w io.Writer, stats *resultsStats) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"group", "name", "repos", "warnings"})
+	out.Write([]string{"total", "", strconv.Itoa(stats.Repos), strconv.Itoa(stats.Warnings)})
+	groups := []struct {
+		name string
+		rows []statsRow
+	}{
+		{"checker", stats.Checkers},
+		{"org", stats.Orgs},
+		{"language", stats.Languages},
+	}
+	for _, g := range groups {
+		for _, row := range g.rows {
+			out.Write([]string{g.name, row.Name, strconv.Itoa(row.Repos), strconv.Itoa(row.Warnings)})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func printStatsJSON(w io.Writer, stats *resultsStats) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrintStatsMarkdownEmptyChecker(t *testing.T) {
+	stats := &resultsStats{
+		Repos: 1,
+		Checkers: []statsRow{
+			{Name: "misspell", Repos: 1, Warnings: 2},
+			{Name: "", Repos: 1, Warnings: 1},
+		},
+	}
+	var buf bytes.Buffer
+	if err := printStatsMarkdown(&buf, stats); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"| Misspell | 2 |\n", "|  | 1 |\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q line in:\n%s", want, buf.String())
+		}
+	}
+}
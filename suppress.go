@@ -0,0 +1,150 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// markdownIgnoreRE matches "<!-- repolint:ignore checkers -->" comment.
+	// The "repolint:ignore-file" form is also matched.
+	markdownIgnoreRE = regexp.MustCompile(`<!--\s*repolint:ignore(-file)?\b(.*?)-->`)
+
+	// rstIgnoreRE matches ".. repolint:ignore checkers" comment.
+	// The "repolint:ignore-file" form is also matched.
+	rstIgnoreRE = regexp.MustCompile(`^\s*\.\.\s+repolint:ignore(-file)?\b(.*)$`)
+)
+
+// lineIgnores maps 1-based line numbers to the checkers
+// that are suppressed on these lines by inline directives.
+// Line 0 lists the checkers that are suppressed in the whole file.
+// An empty checker name suppresses all checkers.
+type lineIgnores map[int][]string
+
+func (li lineIgnores) ignored(line int, checker string) bool {
+	for _, names := range [][]string{li[0], li[line]} {
+		for _, name := range names {
+			if name == "" || name == checker {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseIgnores finds suppression directives in the file contents.
+//
+// A directive that shares a line with some other text applies to
+// that line. Otherwise, it applies to the next block: the lines up to
+// the next blank line or the whole fenced code block.
+//
+// A "repolint:ignore-file" directive applies to the whole file,
+// including the warnings without a line, like the missing code
+// block language markers.
+//
+// Checker names are separated by commas. Without names,
+// all checkers are suppressed.
+func parseIgnores(contents string) lineIgnores {
+	if !strings.Contains(contents, "repolint:ignore") {
+		return nil
+	}
+
+	li := make(lineIgnores)
+	lines := strings.Split(contents, "\n")
+	for i, l := range lines {
+		var names, rest string
+		var wholeFile bool
+		if m := markdownIgnoreRE.FindStringSubmatchIndex(l); m != nil {
+			wholeFile = m[2] != -1
+			names = l[m[4]:m[5]]
+			rest = l[:m[0]] + l[m[1]:]
+		} else if m := rstIgnoreRE.FindStringSubmatch(l); m != nil {
+			wholeFile = m[1] != ""
+			names = m[2]
+		} else {
+			continue
+		}
+
+		var checkers []string
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				checkers = append(checkers, name)
+			}
+		}
+		if len(checkers) == 0 {
+			checkers = []string{""}
+		}
+
+		if wholeFile {
+			li[0] = append(li[0], checkers...)
+			continue
+		}
+		if strings.TrimSpace(rest) != "" {
+			li[i+1] = append(li[i+1], checkers...)
+			continue
+		}
+		j := i + 1
+		for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+			j++
+		}
+		fence := codeFence(lines, j)
+		for start := j; j < len(lines); j++ {
+			if fence == "" && strings.TrimSpace(lines[j]) == "" {
+				break
+			}
+			li[j+1] = append(li[j+1], checkers...)
+			if fence != "" && j != start && strings.HasPrefix(strings.TrimSpace(lines[j]), fence) {
+				break
+			}
+		}
+	}
+	return li
+}
+
+// codeFence returns a fenced code block marker, like "```",
+// if lines[i] opens a code block. Otherwise, returns empty string.
+func codeFence(lines []string, i int) string {
+	if i >= len(lines) {
+		return ""
+	}
+	l := strings.TrimSpace(lines[i])
+	for _, ch := range []string{"`", "~"} {
+		n := len(l) - len(strings.TrimLeft(l, ch))
+		if n >= 3 {
+			return l[:n]
+		}
+	}
+	return ""
+}
+
+// suppressWarnings removes warnings that are suppressed by the config
+// ignore list or by inline directives in the files they point to.
+func (l *linter) suppressWarnings(repo *repository, cfg *config, files []*repoFile, warnings []warning) []warning {
+	filesByName := make(map[string]*repoFile, len(files))
+	for _, f := range files {
+		filesByName[f.origName] = f
+	}
+	ignores := make(map[string]lineIgnores)
+
+	var kept []warning
+	for _, w := range warnings {
+		if cfg.ignored(&w) {
+			continue
+		}
+		// Warnings without a line can only be suppressed by a file
+		// directive. Files are not fetched just to look for it.
+		f := filesByName[w.file]
+		if f != nil && (w.line != 0 || f.require.contents) {
+			li, ok := ignores[w.file]
+			if !ok {
+				li = parseIgnores(l.fileContents(repo, f))
+				ignores[w.file] = li
+			}
+			if li.ignored(w.line, w.checker) {
+				continue
+			}
+		}
+		kept = append(kept, w)
+	}
+	return kept
+}
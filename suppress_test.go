@@ -0,0 +1,166 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseIgnores(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     lineIgnores
+	}{
+		{
+			name:     "no directives",
+			contents: "some sql\n\nteh\n",
+			want:     nil,
+		},
+		{
+			name: "same line",
+			contents: "intro\n" +
+				"Another sql <!-- repolint:ignore acronym -->\n" +
+				"sql\n",
+			want: lineIgnores{2: {"acronym"}},
+		},
+		{
+			name: "next block",
+			contents: "<!-- repolint:ignore acronym, misspell -->\n" +
+				"This sql is\n" +
+				"intentional.\n" +
+				"\n" +
+				"This sql is not.\n",
+			want: lineIgnores{2: {"acronym", "misspell"}, 3: {"acronym", "misspell"}},
+		},
+		{
+			name: "blank lines before block",
+			contents: "<!-- repolint:ignore -->\n" +
+				"\n" +
+				"\n" +
+				"teh sql\n" +
+				"\n" +
+				"teh sql\n",
+			want: lineIgnores{4: {""}},
+		},
+		{
+			name: "fenced code block",
+			contents: "<!-- repolint:ignore misspell -->\n" +
+				"```sh\n" +
+				"echo teh\n" +
+				"\n" +
+				"echo recieve\n" +
+				"```\n" +
+				"teh\n",
+			want: lineIgnores{2: {"misspell"}, 3: {"misspell"}, 4: {"misspell"}, 5: {"misspell"}, 6: {"misspell"}},
+		},
+		{
+			name: "tilde fence",
+			contents: "<!-- repolint:ignore -->\n" +
+				"~~~~\n" +
+				"```\n" +
+				"~~~~\n" +
+				"teh\n",
+			want: lineIgnores{2: {""}, 3: {""}, 4: {""}},
+		},
+		{
+			name: "rst",
+			contents: "Title\n" +
+				"=====\n" +
+				"\n" +
+				".. repolint:ignore acronym,misspell\n" +
+				"\n" +
+				"teh sql\n" +
+				"more sql\n" +
+				"\n" +
+				"sql\n",
+			want: lineIgnores{6: {"acronym", "misspell"}, 7: {"acronym", "misspell"}},
+		},
+		{
+			name: "comma separated",
+			contents: "<!-- repolint:ignore misspell,, acronym , -->\n" +
+				"teh sql\n",
+			want: lineIgnores{2: {"misspell", "acronym"}},
+		},
+		{
+			name: "whole file",
+			contents: "# Title\n" +
+				"<!-- repolint:ignore-file code snippet, acronym -->\n" +
+				"sql <!-- repolint:ignore misspell -->\n" +
+				"\n" +
+				".. repolint:ignore-file\n",
+			want: lineIgnores{0: {"code snippet", "acronym", ""}, 3: {"misspell"}},
+		},
+		{
+			name: "several directives",
+			contents: "<!-- repolint:ignore misspell -->\n" +
+				"teh sql <!-- repolint:ignore acronym -->\n",
+			want: lineIgnores{2: {"misspell", "acronym"}},
+		},
+	}
+
+	for _, test := range tests {
+		have := parseIgnores(test.contents)
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("%s: ignores mismatch:\nhave: %v\nwant: %v", test.name, have, test.want)
+		}
+	}
+}
+
+func TestSuppressWarnings(t *testing.T) {
+	readme := &repoFile{
+		origName: "README.md",
+		contents: "<!-- repolint:ignore misspell -->\n" +
+			"teh sql\n" +
+			"\n" +
+			"teh sql\n",
+	}
+	readme.require.contents = true
+	guide := &repoFile{
+		origName: "docs/guide.md",
+		contents: "<!-- repolint:ignore-file code snippet -->\n" +
+			"teh\n",
+	}
+	guide.require.contents = true
+	cfg, err := parseConfig([]byte(`
+ignore:
+  - checker: broken link
+    path: docs/
+  - checker: missing file
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := []warning{
+		{checker: "misspell", file: "README.md", line: 2, col: 1, message: "teh"},
+		{checker: "acronym", file: "README.md", line: 2, col: 5, message: "sql"},
+		{checker: "misspell", file: "README.md", line: 4, col: 1, message: "teh"},
+		{checker: "broken link", file: "docs/a.md", line: 1, message: "http://a"},
+		{checker: "broken link", file: "b.md", line: 1, message: "http://b"},
+		{checker: "missing file", message: "no LICENSE"},
+		{checker: "unwanted file", file: ".a.swp", message: "remove Vim swap file"},
+		{checker: "code snippet", file: "docs/guide.md", message: "block #1: add \"go\" language marker"},
+		{checker: "code snippet", file: "README.md", message: "block #1: add \"go\" language marker"},
+		{checker: "misspell", file: "docs/guide.md", line: 2, col: 1, message: "teh"},
+	}
+	l := &linter{}
+	have := l.suppressWarnings(&repository{owner: "o", name: "r"}, cfg, []*repoFile{readme, guide}, warnings)
+
+	var messages []string
+	for _, w := range have {
+		messages = append(messages, w.checker+": "+w.String())
+	}
+	want := []string{
+		"acronym: README.md:2:5: sql",
+		"misspell: README.md:4:1: teh",
+		"broken link: b.md:1: http://b",
+		"unwanted file: .a.swp: remove Vim swap file",
+		`code snippet: README.md: block #1: add "go" language marker`,
+		"misspell: docs/guide.md:2:1: teh",
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("kept warnings:\nhave: %s\nwant: %s",
+			strings.Join(messages, "\n      "), strings.Join(want, "\n      "))
+	}
+}
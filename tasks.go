@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// checkerDifficulty maps checker names to the difficulty
+// of fixing their warnings for a first time contributor.
+var checkerDifficulty = map[string]string{
+	"misspell":         "easy",
+	"acronym":          "easy",
+	"var name typo":    "easy",
+	"unwanted file":    "easy",
+	"code snippet":     "easy",
+	"travis lint":      "easy",
+	"broken link":      "medium",
+	"relative link":    "medium",
+	"sloppy copyright": "medium",
+	"readme badge":     "medium",
+	"missing file":     "hard",
+}
+
+// tasksReporter prints a Markdown task list that can be proposed
+// to contribution events participants.
+//
+// Tasks are grouped by repository and checker. Every checker group
+// is expected to be solved with a single pull request.
+type tasksReporter struct {
+	w   io.Writer
+	l   *linter
+	buf bytes.Buffer
+
+	numRepos int
+	numTasks int
+	numPRs   int
+}
+
+func (r *tasksReporter) report(repo *repository, warnings []warning) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	// Warnings are already sorted by the checker name.
+	var groups [][]warning
+	for i, w := range warnings {
+		if i == 0 || w.checker != warnings[i-1].checker {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], w)
+	}
+
+	r.numRepos++
+	r.numTasks += len(warnings)
+	r.numPRs += len(groups)
+
+	title := r.l.repoPath(repo)
+	if repo.webURL != "" {
+		title = fmt.Sprintf("[%s](%s)", title, repo.webURL)
+	}
+	fmt.Fprintf(&r.buf, "\n## %s\n\nEstimated pull requests: %d.\n", title, len(groups))
+	for _, g := range groups {
+		name := g[0].checker
+		difficulty := checkerDifficulty[name]
+		if difficulty == "" {
+			difficulty = "medium"
+		}
+		fmt.Fprintf(&r.buf, "\n### %s (%s)\n\n", name, difficulty)
+		for _, w := range g {
+			fmt.Fprintf(&r.buf, "- [ ] %s\n", r.taskText(repo, &w))
+		}
+	}
+	return nil
+}
+
+func (r *tasksReporter) finish(s *runSummary) error {
+	title := "Tasks"
+	if r.l.user != "" {
+		title += ": " + r.l.user
+	}
+	_, err := fmt.Fprintf(r.w, "# %s\n\n%d tasks in %d repositories, estimated pull requests: %d.\n%s",
+		title, r.numTasks, r.numRepos, r.numPRs, r.buf.String())
+	return err
+}
+
+// taskText returns a warning formatted as a task list item text.
+// Warning position is linked to the file line, if possible.
+func (r *tasksReporter) taskText(repo *repository, w *warning) string {
+	msg := markdownEscape(w.message)
+	if w.file == "" {
+		return msg
+	}
+	pos := "`" + w.position() + "`"
+	if u := fileURL(r.l.providerKind, repo, w.file, w.line); u != "" {
+		pos = fmt.Sprintf("[%s](%s)", pos, u)
+	}
+	return pos + ": " + msg
+}
+
+// markdownEscape escapes characters that can break Markdown inline text.
+var markdownEscape = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `&lt;`,
+).Replace
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// severity is a warning importance level.
+type severity int
+
+const (
+	severityInfo severity = iota
+	severityWarning
+	severityError
+)
+
+func (s severity) String() string {
+	switch s {
+	case severityInfo:
+		return "info"
+	case severityWarning:
+		return "warning"
+	case severityError:
+		return "error"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// parseSeverity returns a severity by its name.
+func parseSeverity(name string) (severity, error) {
+	for s := severityInfo; s <= severityError; s++ {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity %q, expected info, warning or error", name)
+}
+
+// warning is a single checker report.
+type warning struct {
+	// checker is a name of the checker that produced the warning.
+	// It's filled by the linter, checkers leave it empty.
+	checker string
+
+	// file is a slash-separated repository file path.
+	// Empty for repository-level warnings.
+	file string
+
+	// line and col are 1-based warning position inside the file.
+	// Zero values mean that the position is unknown.
+	line int
+	col  int
+
+	// lineText is the warning line contents, if the file contents
+	// were fetched. It's used to convert col, that is a byte offset,
+	// to other units, like UTF-16 code units.
+	lineText string
+
+	message  string
+	severity severity
+
+	// suggestion is a suggested replacement for the original text
+	// found at line:col. Empty if there is nothing to suggest.
+	suggestion string
+	original   string
+}
+
+// fileLine returns a 1-based line of the contents.
+// Returns empty string if there is no such line.
+func fileLine(contents string, line int) string {
+	lines := strings.SplitN(contents, "\n", line+1)
+	if line > len(lines) {
+		return ""
+	}
+	return strings.TrimSuffix(lines[line-1], "\r")
+}
+
+// String returns a warning text without the checker name.
+func (w *warning) String() string {
+	if w.file == "" {
+		return w.message
+	}
+	return w.position() + ": " + w.message
+}
+
+// position returns a warning position formatted as "file:line:col",
+// like compilers do. Unknown line and column are omitted.
+func (w *warning) position() string {
+	switch {
+	case w.line == 0:
+		return w.file
+	case w.col == 0:
+		return fmt.Sprintf("%s:%d", w.file, w.line)
+	default:
+		return fmt.Sprintf("%s:%d:%d", w.file, w.line, w.col)
+	}
+}
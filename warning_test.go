@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestWarningString(t *testing.T) {
+	tests := []struct {
+		w    warning
+		want string
+	}{
+		{warning{message: "no README"}, "no README"},
+		{warning{file: ".a.swp", message: "remove Vim swap file"}, ".a.swp: remove Vim swap file"},
+		{warning{file: "README.md", line: 3, message: "msg"}, "README.md:3: msg"},
+		{warning{file: "docs/a.md", line: 3, col: 7, message: "msg"}, "docs/a.md:3:7: msg"},
+		// Column without a line is not printed.
+		{warning{file: "README.md", col: 7, message: "msg"}, "README.md: msg"},
+	}
+	for _, test := range tests {
+		if got := test.w.String(); got != test.want {
+			t.Errorf("%+v: got %q, want %q", test.w, got, test.want)
+		}
+	}
+}